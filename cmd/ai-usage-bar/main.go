@@ -4,33 +4,63 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/jhartzell/ai-usage-bar/internal/cache"
+	"github.com/jhartzell/ai-usage-bar/internal/config"
 	"github.com/jhartzell/ai-usage-bar/internal/detail"
+	"github.com/jhartzell/ai-usage-bar/internal/diag"
+	"github.com/jhartzell/ai-usage-bar/internal/doctor"
+	"github.com/jhartzell/ai-usage-bar/internal/projects"
 	"github.com/jhartzell/ai-usage-bar/internal/provider"
 	"github.com/jhartzell/ai-usage-bar/internal/recovery"
+	"github.com/jhartzell/ai-usage-bar/internal/report"
 	"github.com/jhartzell/ai-usage-bar/internal/waybar"
 )
 
 func main() {
-	if handled := handleCommand(os.Args[1:]); handled {
+	args, opts, err := extractOptions(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n\n", err)
+		printUsage()
+		os.Exit(2)
+	}
+	setupDiagnostics(opts.verbose)
+	defer diag.Close()
+	if err := setupTape(opts); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if handled := handleCommand(args); handled {
 		return
 	}
 
-	results := cache.Load()
+	// A tape always goes to the providers, and its results, recorded or
+	// replayed, are kept out of the cache.
+	taped := opts.record != "" || opts.replay != ""
+	var results []provider.Result
+	if !taped {
+		results = cache.Load()
+	}
 	if results == nil {
-		providers := []provider.Provider{
-			provider.Claude{},
-			provider.Codex{},
-			provider.OpenRouter{},
-		}
 		ctx := context.Background()
-		results = provider.FetchAll(ctx, providers)
-		cache.Save(results)
+		results = provider.FetchAll(ctx, provider.Default())
+		if !taped {
+			cache.Save(results)
+		}
 	}
 
-	if len(os.Args) > 1 && os.Args[1] == "--detail" {
-		detail.ShowYad(results)
+	if len(args) > 0 && args[0] == "--detail" {
+		// Project usage is extra context; the popup still opens without it.
+		rep, _ := projects.Collect(context.Background(), time.Now(), "")
+		detail.ShowYad(results, rep)
+		return
+	}
+
+	if len(args) > 0 && args[0] == "--json" {
+		fmt.Println(report.JSON(results))
 		return
 	}
 
@@ -38,13 +68,79 @@ func main() {
 	fmt.Println(waybar.FormatJSON(output))
 }
 
+// options are the flags that combine with any command.
+type options struct {
+	verbose bool
+	record  string
+	replay  string
+}
+
+// extractOptions removes --verbose, --record <dir> and --replay <dir> from
+// args.
+func extractOptions(args []string) ([]string, options, error) {
+	var opts options
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch a := args[i]; a {
+		case "--verbose", "-v":
+			opts.verbose = true
+		case "--record", "--replay":
+			if i+1 >= len(args) {
+				return nil, opts, fmt.Errorf("%s needs a directory", a)
+			}
+			i++
+			if a == "--record" {
+				opts.record = args[i]
+			} else {
+				opts.replay = args[i]
+			}
+		default:
+			rest = append(rest, a)
+		}
+	}
+	if opts.record != "" && opts.replay != "" {
+		return nil, opts, fmt.Errorf("--record and --replay can't be combined")
+	}
+	return rest, opts, nil
+}
+
+func setupTape(opts options) error {
+	switch {
+	case opts.record != "":
+		return provider.Record(opts.record)
+	case opts.replay != "":
+		return provider.Replay(opts.replay)
+	}
+	return nil
+}
+
+// setupDiagnostics logs to stderr with --verbose and to the config file's
+// log file when one is set. A broken config is reported by the providers,
+// so it is ignored here.
+func setupDiagnostics(verbose bool) {
+	if verbose {
+		diag.Enable(os.Stderr)
+	}
+	cfg, err := config.Load()
+	if err != nil || cfg.Log.File == "" {
+		return
+	}
+	path := config.ExpandHome(cfg.Log.File)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err == nil {
+		err = diag.OpenFile(path, int64(cfg.Log.MaxSizeKB)*1024)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "log file: %v\n", err)
+	}
+}
+
 func handleCommand(args []string) bool {
 	if len(args) == 0 {
 		return false
 	}
 
 	switch args[0] {
-	case "--detail":
+	case "--detail", "--json":
 		return false
 	case "--recover-auth":
 		if err := recovery.RunAuthRecovery(context.Background(), os.Stdin, os.Stdout, os.Stderr); err != nil {
@@ -52,6 +148,25 @@ func handleCommand(args []string) bool {
 			os.Exit(1)
 		}
 		return true
+	case "--projects":
+		period := ""
+		if len(args) > 1 {
+			period = args[1]
+		}
+		rep, err := projects.Collect(context.Background(), time.Now(), period)
+		if err == nil {
+			err = projects.Write(os.Stdout, rep)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return true
+	case "--doctor":
+		if !doctor.Run(context.Background(), os.Stdout) {
+			os.Exit(1)
+		}
+		return true
 	case "--clear-cache":
 		if err := cache.Clear(); err != nil {
 			fmt.Fprintln(os.Stderr, err)
@@ -71,9 +186,16 @@ func handleCommand(args []string) bool {
 }
 
 func printUsage() {
-	fmt.Println("Usage: ai-usage-bar [--detail|--recover-auth|--clear-cache]")
+	fmt.Println("Usage: ai-usage-bar [--verbose] [--record DIR|--replay DIR] [--detail|--json|--projects [today|week|month]|--doctor|--recover-auth|--clear-cache]")
 	fmt.Println()
 	fmt.Println("  --detail         Open popup with provider details")
+	fmt.Println("  --json           Print full provider details as JSON")
+	fmt.Println("  --projects       Print token usage and estimated cost per project")
+	fmt.Println("                   (default period: week, or projects.period in config)")
+	fmt.Println("  --doctor         Check the config file and provider connectivity")
 	fmt.Println("  --recover-auth   Run provider login flows and clear cache")
 	fmt.Println("  --clear-cache    Remove cached usage data")
+	fmt.Println("  --verbose, -v    Log requests, token refreshes and cache decisions to stderr")
+	fmt.Println("  --record DIR     Save sanitized provider requests and responses to DIR")
+	fmt.Println("  --replay DIR     Answer provider requests from a recording instead of the network")
 }
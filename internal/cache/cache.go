@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/jhartzell/ai-usage-bar/internal/diag"
 	"github.com/jhartzell/ai-usage-bar/internal/provider"
 )
 
@@ -18,15 +19,20 @@ type entry struct {
 }
 
 type cachedResult struct {
-	Name     string                `json:"name"`
-	Identity string                `json:"identity,omitempty"`
-	Short    string                `json:"short,omitempty"`
-	Class    string                `json:"class,omitempty"`
-	Windows  []provider.RateWindow `json:"windows,omitempty"`
-	Spend    []provider.SpendEntry `json:"spend,omitempty"`
-	Credits  *float64              `json:"credits,omitempty"`
-	Plan     string                `json:"plan,omitempty"`
-	Error    string                `json:"error,omitempty"`
+	Name      string                    `json:"name"`
+	Identity  string                    `json:"identity,omitempty"`
+	Short     string                    `json:"short,omitempty"`
+	Class     string                    `json:"class,omitempty"`
+	Windows   []provider.RateWindow     `json:"windows,omitempty"`
+	Spend     []provider.SpendEntry     `json:"spend,omitempty"`
+	Keys      []provider.KeyUsage       `json:"keys,omitempty"`
+	Breakdown []provider.BreakdownEntry `json:"breakdown,omitempty"`
+	Credits   *float64                  `json:"credits,omitempty"`
+	Currency  string                    `json:"currency,omitempty"`
+	Tokens    []provider.TokenPeriod    `json:"tokens,omitempty"`
+	Plan      string                    `json:"plan,omitempty"`
+	Error     string                    `json:"error,omitempty"`
+	Source    string                    `json:"source,omitempty"`
 }
 
 func cacheDir() (string, error) {
@@ -55,35 +61,47 @@ func Load() []provider.Result {
 
 	data, err := os.ReadFile(path)
 	if err != nil {
+		diag.Logf("cache: miss: %v", err)
 		return nil
 	}
 
 	var e entry
 	if err := json.Unmarshal(data, &e); err != nil {
+		diag.Logf("cache: ignoring corrupt %s: %v", path, err)
 		return nil
 	}
 
+	// Errors and offline fallbacks are not reused so the next run retries
+	// the live source.
 	for _, cr := range e.Results {
-		if cr.Error != "" {
+		if cr.Error != "" || cr.Source != "" {
+			diag.Logf("cache: not reusing %s's error or fallback result", cr.Name)
 			return nil
 		}
 	}
 
-	if time.Since(e.FetchedAt) > maxAge {
+	age := time.Since(e.FetchedAt)
+	if age > maxAge {
+		diag.Logf("cache: stale (%v old)", age.Round(time.Second))
 		return nil
 	}
+	diag.Logf("cache: hit (%v old)", age.Round(time.Second))
 
 	results := make([]provider.Result, len(e.Results))
 	for i, cr := range e.Results {
 		r := provider.Result{
-			Name:     cr.Name,
-			Identity: cr.Identity,
-			Short:    cr.Short,
-			Class:    cr.Class,
-			Windows:  cr.Windows,
-			Spend:    cr.Spend,
-			Credits:  cr.Credits,
-			Plan:     cr.Plan,
+			Name:      cr.Name,
+			Identity:  cr.Identity,
+			Short:     cr.Short,
+			Class:     cr.Class,
+			Windows:   cr.Windows,
+			Spend:     cr.Spend,
+			Keys:      cr.Keys,
+			Breakdown: cr.Breakdown,
+			Credits:   cr.Credits,
+			Currency:  cr.Currency,
+			Tokens:    cr.Tokens,
+			Plan:      cr.Plan,
 		}
 		if cr.Error != "" {
 			r.Error = errors.New(cr.Error)
@@ -106,14 +124,19 @@ func Save(results []provider.Result) {
 	cached := make([]cachedResult, len(results))
 	for i, r := range results {
 		cr := cachedResult{
-			Name:     r.Name,
-			Identity: r.Identity,
-			Short:    r.Short,
-			Class:    r.Class,
-			Windows:  r.Windows,
-			Spend:    r.Spend,
-			Credits:  r.Credits,
-			Plan:     r.Plan,
+			Name:      r.Name,
+			Identity:  r.Identity,
+			Short:     r.Short,
+			Class:     r.Class,
+			Windows:   r.Windows,
+			Spend:     r.Spend,
+			Keys:      r.Keys,
+			Breakdown: r.Breakdown,
+			Credits:   r.Credits,
+			Currency:  r.Currency,
+			Tokens:    r.Tokens,
+			Plan:      r.Plan,
+			Source:    r.Source,
 		}
 		if r.Error != nil {
 			cr.Error = r.Error.Error()
@@ -131,7 +154,11 @@ func Save(results []provider.Result) {
 		return
 	}
 
-	os.WriteFile(path, data, 0o600)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		diag.Logf("cache: save failed: %v", err)
+		return
+	}
+	diag.Logf("cache: saved %d results", len(results))
 }
 
 // Clear removes the cache file if it exists.
@@ -33,7 +33,13 @@ func TestSaveAndLoadRoundTrip(t *testing.T) {
 				{Label: "Session (5h)", UsedPct: 45, HasReset: true, ResetAt: time.Unix(1_700_000_000, 0)},
 			},
 			Spend:   []provider.SpendEntry{{Label: "This month", Amount: 3.21}},
+			Keys:    []provider.KeyUsage{{Label: "agents", Monthly: 1.5, Class: "warning"}},
 			Credits: &credits,
+			Tokens: []provider.TokenPeriod{{
+				Label:      "Today",
+				TokenCount: provider.TokenCount{Input: 10, Output: 20},
+				Models:     []provider.TokenRow{{Label: "claude-sonnet", TokenCount: provider.TokenCount{Output: 20}}},
+			}},
 		},
 	}
 
@@ -63,6 +69,12 @@ func TestSaveAndLoadRoundTrip(t *testing.T) {
 	if len(got.Spend) != 1 || got.Spend[0].Amount != 3.21 {
 		t.Fatalf("unexpected spend: %#v", got.Spend)
 	}
+	if len(got.Keys) != 1 || got.Keys[0].Label != "agents" || got.Keys[0].Class != "warning" {
+		t.Fatalf("unexpected keys: %#v", got.Keys)
+	}
+	if len(got.Tokens) != 1 || got.Tokens[0].Total() != 30 || len(got.Tokens[0].Models) != 1 {
+		t.Fatalf("unexpected tokens: %#v", got.Tokens)
+	}
 }
 
 func TestLoadReturnsNilForStaleCache(t *testing.T) {
@@ -93,6 +105,16 @@ func TestLoadReturnsNilWhenAnyProviderErrorCached(t *testing.T) {
 	}
 }
 
+func TestLoadReturnsNilWhenAnyResultIsOfflineFallback(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	Save([]provider.Result{{Name: "Codex", Short: "40%", Source: "session log", AsOf: time.Now()}})
+
+	if got := Load(); got != nil {
+		t.Fatalf("expected nil when cache contains a fallback result, got %#v", got)
+	}
+}
+
 func TestLoadReturnsNilForCorruptJSON(t *testing.T) {
 	t.Setenv("XDG_CACHE_HOME", t.TempDir())
 
@@ -0,0 +1,283 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jhartzell/ai-usage-bar/internal/diag"
+	"github.com/jhartzell/ai-usage-bar/internal/pricing"
+)
+
+// Config is the optional user configuration file,
+// ~/.config/ai-usage-bar/config.json.
+type Config struct {
+	Providers []Provider `json:"providers"`
+	// Pricing overrides or extends the built-in per-model prices (USD per
+	// million tokens) used for API-equivalent cost estimates.
+	Pricing map[string]pricing.Price `json:"pricing,omitempty"`
+	// Projects controls how local token usage is attributed to projects.
+	Projects Projects `json:"projects,omitempty"`
+	// Network configures how provider requests reach their endpoints.
+	Network Network `json:"network,omitempty"`
+	// Endpoints points built-in providers at other base URLs, keyed by
+	// endpoint name (see README), e.g. {"claude": "https://gw.corp/claude"}.
+	Endpoints map[string]string `json:"endpoints,omitempty"`
+	// Log turns on the diagnostic log file.
+	Log Log `json:"log,omitempty"`
+}
+
+// Log configures the diagnostic log of provider requests, token refreshes
+// and cache decisions. It is off unless File is set.
+type Log struct {
+	// File is the log path, ~ expanded. It is moved to File+".1" once it
+	// grows past MaxSizeKB (default 1024).
+	File      string `json:"file,omitempty"`
+	MaxSizeKB int    `json:"max_size_kb,omitempty"`
+}
+
+// Network holds proxy, TLS and timeout settings for provider requests.
+// Unset fields keep Go's defaults, including HTTPS_PROXY and friends.
+type Network struct {
+	// Proxy is a proxy URL, or "direct" to bypass any proxy.
+	Proxy string `json:"proxy,omitempty"`
+	// CAFiles are extra PEM bundles trusted alongside the system roots,
+	// e.g. for a TLS-intercepting corporate proxy.
+	CAFiles []string `json:"ca_files,omitempty"`
+	// ConnectTimeout bounds the TCP and TLS handshakes and ReadTimeout the
+	// wait for response headers, as Go durations such as "3s".
+	ConnectTimeout string `json:"connect_timeout,omitempty"`
+	ReadTimeout    string `json:"read_timeout,omitempty"`
+	// Providers overrides settings per provider, keyed by provider name.
+	Providers map[string]NetworkOverride `json:"providers,omitempty"`
+}
+
+type NetworkOverride struct {
+	Proxy string `json:"proxy,omitempty"`
+}
+
+// Projects names and groups the working directories that token usage is
+// attributed to. Usage from a directory that no alias matches is
+// attributed to the git repository containing it.
+type Projects struct {
+	// Period is the popup's default period: today, week or month.
+	Period  string         `json:"period,omitempty"`
+	Aliases []ProjectAlias `json:"aliases,omitempty"`
+}
+
+// ProjectAlias attributes every directory at or below a path matching
+// Match (a filepath.Match pattern, ~ expanded) to Name. Aliases are tried
+// in order; giving several the same Name groups them.
+type ProjectAlias struct {
+	Match string `json:"match"`
+	Name  string `json:"name"`
+}
+
+// Provider declares an extra provider. Type selects the implementation;
+// the remaining fields are read by that implementation.
+type Provider struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+
+	// generic: an HTTP request and JSONPath-style mappings from its response.
+	URL      string            `json:"url,omitempty"`
+	Method   string            `json:"method,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Body     string            `json:"body,omitempty"`
+	Identity string            `json:"identity,omitempty"`
+	Plan     string            `json:"plan,omitempty"`
+	Credits  string            `json:"credits,omitempty"`
+	Currency string            `json:"currency,omitempty"`
+	Windows  []WindowMapping   `json:"windows,omitempty"`
+	Spend    []SpendMapping    `json:"spend,omitempty"`
+
+	// probe: a minimal request (URL, Method, Headers and Body as for
+	// generic) whose rate-limit response headers become windows. Preset
+	// fills in a known vendor's request and headers.
+	Preset string         `json:"preset,omitempty"`
+	Limits []LimitHeaders `json:"limits,omitempty"`
+
+	// plugin: an executable speaking the plugin protocol (see README).
+	Command  string          `json:"command,omitempty"`
+	Args     []string        `json:"args,omitempty"`
+	Timeout  string          `json:"timeout,omitempty"`
+	Settings json.RawMessage `json:"settings,omitempty"`
+}
+
+// WindowMapping maps a usage window. Either Percent (0-100) or Used and
+// Limit must be set; Reset is optional and may be an RFC 3339 string or a
+// unix timestamp in seconds or milliseconds.
+type WindowMapping struct {
+	Label   string `json:"label"`
+	Percent string `json:"percent,omitempty"`
+	Used    string `json:"used,omitempty"`
+	Limit   string `json:"limit,omitempty"`
+	Reset   string `json:"reset,omitempty"`
+}
+
+// LimitHeaders names the response headers describing one rate limit.
+// Reset is optional and may hold an RFC 3339 time, a duration such as
+// "6m0s", or seconds.
+type LimitHeaders struct {
+	Label     string `json:"label"`
+	Limit     string `json:"limit"`
+	Remaining string `json:"remaining"`
+	Reset     string `json:"reset,omitempty"`
+}
+
+type SpendMapping struct {
+	Label  string `json:"label"`
+	Amount string `json:"amount"`
+}
+
+// Path returns the config file location, honouring XDG_CONFIG_HOME.
+func Path() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "ai-usage-bar", "config.json"), nil
+}
+
+// Load reads the config file. A missing file is not an error and yields an
+// empty Config.
+func Load() (*Config, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	for i, p := range cfg.Providers {
+		if p.Type == "" {
+			return nil, fmt.Errorf("%s: provider %d has no type", path, i+1)
+		}
+		if p.Name == "" {
+			return nil, fmt.Errorf("%s: provider %d has no name", path, i+1)
+		}
+	}
+	switch cfg.Projects.Period {
+	case "", "today", "week", "month":
+	default:
+		return nil, fmt.Errorf("%s: projects period must be today, week or month", path)
+	}
+	for key, u := range cfg.Endpoints {
+		if parsed, err := url.Parse(u); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return nil, fmt.Errorf("%s: endpoint %s: bad URL %q", path, key, u)
+		}
+	}
+	if cfg.Log.MaxSizeKB < 0 {
+		return nil, fmt.Errorf("%s: log: max_size_kb must not be negative", path)
+	}
+	if err := cfg.Network.validate(); err != nil {
+		return nil, fmt.Errorf("%s: network: %w", path, err)
+	}
+	for i, a := range cfg.Projects.Aliases {
+		if a.Match == "" || a.Name == "" {
+			return nil, fmt.Errorf("%s: project alias %d needs match and name", path, i+1)
+		}
+		if _, err := filepath.Match(ExpandHome(a.Match), ""); err != nil {
+			return nil, fmt.Errorf("%s: project alias %d: bad pattern %q", path, i+1, a.Match)
+		}
+	}
+
+	return &cfg, nil
+}
+
+func (n Network) validate() error {
+	if err := validateProxy(n.Proxy); err != nil {
+		return err
+	}
+	for name, o := range n.Providers {
+		if err := validateProxy(o.Proxy); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	for _, d := range []string{n.ConnectTimeout, n.ReadTimeout} {
+		if d == "" {
+			continue
+		}
+		if v, err := time.ParseDuration(d); err != nil || v <= 0 {
+			return fmt.Errorf("bad timeout %q", d)
+		}
+	}
+	return nil
+}
+
+func validateProxy(proxy string) error {
+	if proxy == "" || proxy == "direct" {
+		return nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("bad proxy URL %q", proxy)
+	}
+	return nil
+}
+
+var secretRef = regexp.MustCompile(`\$\{(env|file):([^}]+)\}`)
+
+// Expand resolves secret references in s so credentials stay out of the
+// config file: ${env:NAME} is replaced by an environment variable and
+// ${file:PATH} by a file's contents (trimmed, ~ expanded). Unset variables
+// and unreadable files are errors.
+func Expand(s string) (string, error) {
+	var firstErr error
+	out := secretRef.ReplaceAllStringFunc(s, func(ref string) string {
+		m := secretRef.FindStringSubmatch(ref)
+		kind, name := m[1], strings.TrimSpace(m[2])
+
+		switch kind {
+		case "env":
+			v, ok := os.LookupEnv(name)
+			if !ok && firstErr == nil {
+				firstErr = fmt.Errorf("environment variable %s is not set", name)
+			}
+			diag.AddSecret(v)
+			return v
+		default:
+			data, err := os.ReadFile(ExpandHome(name))
+			if err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("read secret file: %w", err)
+			}
+			secret := strings.TrimSpace(string(data))
+			diag.AddSecret(secret)
+			return secret
+		}
+	})
+	return out, firstErr
+}
+
+// ExpandHome replaces a leading ~ in path with the user's home directory.
+func ExpandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeConfig(t *testing.T, body string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	if err := os.MkdirAll(filepath.Join(dir, "ai-usage-bar"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ai-usage-bar", "config.json"), []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadMissingFileIsEmpty(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Providers) != 0 {
+		t.Fatalf("expected no providers, got %+v", cfg.Providers)
+	}
+}
+
+func TestLoadProviders(t *testing.T) {
+	writeConfig(t, `{"providers":[{
+	  "type":"generic","name":"Gateway","url":"https://gw.example/usage",
+	  "headers":{"Authorization":"Bearer ${env:GW_TOKEN}"},
+	  "windows":[{"label":"Daily","percent":"$.daily.pct","reset":"$.daily.reset"}],
+	  "spend":[{"label":"Today","amount":"$.spend"}]
+	}]}`)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Providers) != 1 {
+		t.Fatalf("expected one provider, got %d", len(cfg.Providers))
+	}
+	p := cfg.Providers[0]
+	if p.Name != "Gateway" || len(p.Windows) != 1 || p.Windows[0].Percent != "$.daily.pct" || len(p.Spend) != 1 {
+		t.Fatalf("unexpected provider: %+v", p)
+	}
+}
+
+func TestLoadRejectsInvalidConfig(t *testing.T) {
+	writeConfig(t, `{"providers":[{"name":"Gateway"}]}`)
+	if _, err := Load(); err == nil {
+		t.Fatal("expected missing type error")
+	}
+
+	writeConfig(t, `{"providers":[`)
+	if _, err := Load(); err == nil {
+		t.Fatal("expected parse error")
+	}
+
+	writeConfig(t, `{"projects":{"aliases":[{"match":"~/work/[","name":"Work"}]}}`)
+	if _, err := Load(); err == nil {
+		t.Fatal("expected bad alias pattern error")
+	}
+
+	writeConfig(t, `{"projects":{"period":"fortnight"}}`)
+	if _, err := Load(); err == nil {
+		t.Fatal("expected bad period error")
+	}
+
+	writeConfig(t, `{"network":{"proxy":"proxy.corp:3128"}}`)
+	if _, err := Load(); err == nil {
+		t.Fatal("expected bad proxy error")
+	}
+
+	writeConfig(t, `{"network":{"connect_timeout":"3"}}`)
+	if _, err := Load(); err == nil {
+		t.Fatal("expected bad timeout error")
+	}
+
+	writeConfig(t, `{"endpoints":{"claude":"gateway.corp/claude"}}`)
+	if _, err := Load(); err == nil {
+		t.Fatal("expected bad endpoint URL error")
+	}
+
+	writeConfig(t, `{"log":{"file":"~/debug.log","max_size_kb":-1}}`)
+	if _, err := Load(); err == nil {
+		t.Fatal("expected bad log size error")
+	}
+}
+
+func TestExpandSecretReferences(t *testing.T) {
+	t.Setenv("GW_TOKEN", "tok-123")
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.WriteFile(filepath.Join(home, "secret"), []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Expand("Bearer ${env:GW_TOKEN} ${file:~/secret}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "Bearer tok-123 s3cret" {
+		t.Fatalf("unexpected expansion: %q", got)
+	}
+
+	if _, err := Expand("${env:AI_USAGE_BAR_UNSET_VAR}"); err == nil {
+		t.Fatal("expected unset variable error")
+	}
+	if _, err := Expand("${file:/nonexistent/secret}"); err == nil {
+		t.Fatal("expected missing file error")
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/jhartzell/ai-usage-bar/internal/projects"
 	"github.com/jhartzell/ai-usage-bar/internal/provider"
 )
 
@@ -23,6 +24,7 @@ var popupHTMLTemplate = template.Must(template.New("popup").Parse(popupTemplate)
 
 type popupData struct {
 	Providers        []providerView
+	Projects         *projectsView
 	ShowRecoverAuth  bool
 	RecoverAuthLabel string
 }
@@ -32,12 +34,17 @@ type providerView struct {
 	Name         string
 	Plan         string
 	Identity     string
+	Source       string
 	Error        string
 	Windows      []windowView
 	Spend        []spendView
+	Keys         []keyView
+	Breakdown    []breakdownView
+	Tokens       []tokenView
 	ShowCredits  bool
 	CreditsLabel string
 	CreditsValue float64
+	Symbol       string
 	NoData       bool
 }
 
@@ -49,12 +56,65 @@ type windowView struct {
 }
 
 type spendView struct {
+	Label    string
+	Amount   float64
+	Symbol   string
+	Estimate bool
+}
+
+type breakdownView struct {
+	Title string
+	Rows  []breakdownRowView
+}
+
+type breakdownRowView struct {
 	Label  string
 	Amount float64
+	Detail string
+}
+
+type tokenView struct {
+	Title    string
+	Total    string
+	Detail   string
+	Models   []tokenRowView
+	Projects []tokenRowView
+}
+
+type tokenRowView struct {
+	Label string
+	Total string
+}
+
+type projectsView struct {
+	Title string
+	Rows  []projectRowView
+}
+
+type projectRowView struct {
+	Label  string
+	Total  string
+	Cost   string
+	Detail string
+}
+
+// popupProjects caps the projects listed in the popup; --projects lists
+// them all.
+const popupProjects = 6
+
+type keyView struct {
+	Label    string
+	Color    string
+	Limit    string
+	Daily    float64
+	Weekly   float64
+	Monthly  float64
+	Disabled bool
 }
 
-func ShowYad(results []provider.Result) {
-	action, err := showYadOnce(results)
+// ShowYad opens the popup. rep may be nil when project usage is unavailable.
+func ShowYad(results []provider.Result, rep *projects.Report) {
+	action, err := showYadOnce(results, rep)
 	if err != nil {
 		return
 	}
@@ -65,9 +125,9 @@ func ShowYad(results []provider.Result) {
 	}
 }
 
-func showYadOnce(results []provider.Result) (string, error) {
-	htmlDoc := renderHTML(results)
-	width, height := popupSize(results)
+func showYadOnce(results []provider.Result, rep *projects.Report) (string, error) {
+	htmlDoc := renderHTML(results, rep)
+	width, height := popupSize(results, rep)
 
 	cmd := exec.Command("yad",
 		"--html",
@@ -124,11 +184,12 @@ func showYadOnce(results []provider.Result) (string, error) {
 	}
 }
 
-func renderHTML(results []provider.Result) string {
+func renderHTML(results []provider.Result, rep *projects.Report) string {
 	data := popupData{Providers: make([]providerView, 0, len(results))}
 	for _, r := range results {
 		data.Providers = append(data.Providers, toProviderView(r))
 	}
+	data.Projects = toProjectsView(rep)
 
 	data.ShowRecoverAuth = shouldShowRecoverAuth(results)
 	data.RecoverAuthLabel = "Recover auth"
@@ -222,16 +283,57 @@ func toProviderView(r provider.Result) providerView {
 		Name:     r.Name,
 		Plan:     r.Plan,
 		Identity: r.Identity,
-		Windows:  make([]windowView, 0, len(r.Windows)),
 		Spend:    make([]spendView, 0, len(r.Spend)),
+		Symbol:   provider.CurrencySymbol(r.Currency),
+		Tokens:   toTokenViews(r.Tokens),
+		Source:   sourceText(r.Source, r.AsOf),
 	}
 
 	if r.Error != nil {
 		v.Error = r.Error.Error()
+		// Limits from a local snapshot and estimates from local token
+		// counts are still worth showing next to the error that kept the
+		// live data from loading.
+		if r.Source != "" {
+			v.Windows = toWindowViews(r.Windows)
+		}
+		for _, s := range r.Spend {
+			if s.Estimate {
+				v.Spend = append(v.Spend, toSpendView(s, v.Symbol))
+			}
+		}
 		return v
 	}
 
-	for _, w := range r.Windows {
+	v.Windows = toWindowViews(r.Windows)
+
+	for _, s := range r.Spend {
+		v.Spend = append(v.Spend, toSpendView(s, v.Symbol))
+	}
+
+	for _, k := range r.Keys {
+		v.Keys = append(v.Keys, toKeyView(k))
+	}
+
+	v.Breakdown = toBreakdownViews(r.Breakdown)
+
+	if r.Credits != nil {
+		v.ShowCredits = true
+		v.CreditsValue = *r.Credits
+		if r.Name == "Claude" {
+			v.CreditsLabel = "Extra usage remaining"
+		} else {
+			v.CreditsLabel = "Credits"
+		}
+	}
+
+	v.NoData = len(v.Windows) == 0 && len(v.Spend) == 0 && len(v.Keys) == 0 && len(v.Breakdown) == 0 && len(v.Tokens) == 0 && !v.ShowCredits
+	return v
+}
+
+func toWindowViews(windows []provider.RateWindow) []windowView {
+	views := make([]windowView, 0, len(windows))
+	for _, w := range windows {
 		usedPct := clampPct(w.UsedPct)
 		resetStr := ""
 		if w.HasReset {
@@ -241,35 +343,142 @@ func toProviderView(r provider.Result) providerView {
 			}
 		}
 
-		v.Windows = append(v.Windows, windowView{
+		views = append(views, windowView{
 			Label:   w.Label,
 			UsedPct: usedPct,
 			Color:   colorForPct(usedPct),
 			Reset:   resetStr,
 		})
 	}
+	return views
+}
 
-	for _, s := range r.Spend {
-		v.Spend = append(v.Spend, spendView{
-			Label:  s.Label,
-			Amount: s.Amount,
+func toSpendView(s provider.SpendEntry, symbol string) spendView {
+	return spendView{
+		Label:    s.Label,
+		Amount:   s.Amount,
+		Symbol:   symbol,
+		Estimate: s.Estimate,
+	}
+}
+
+// toBreakdownViews groups breakdown rows into tables, keeping the order in
+// which each group first appears.
+func toBreakdownViews(entries []provider.BreakdownEntry) []breakdownView {
+	var views []breakdownView
+	index := map[string]int{}
+	for _, e := range entries {
+		i, ok := index[e.Group]
+		if !ok {
+			i = len(views)
+			index[e.Group] = i
+			views = append(views, breakdownView{Title: e.Group})
+		}
+		var detail []string
+		if e.Requests > 0 {
+			detail = append(detail, fmt.Sprintf("%d req", e.Requests))
+		}
+		if e.Tokens > 0 {
+			detail = append(detail, projects.FormatTokens(e.Tokens)+" tok")
+		}
+		views[i].Rows = append(views[i].Rows, breakdownRowView{
+			Label:  e.Label,
+			Amount: e.Amount,
+			Detail: strings.Join(detail, " · "),
 		})
 	}
+	return views
+}
 
-	if r.Credits != nil {
-		v.ShowCredits = true
-		v.CreditsValue = *r.Credits
-		if r.Name == "Claude" {
-			v.CreditsLabel = "Extra usage remaining"
-		} else {
-			v.CreditsLabel = "Credits"
+// tokenViewModels caps the model rows shown under each token period.
+const tokenViewModels = 3
+
+// toTokenViews renders locally counted token periods. They are shown even
+// for errored providers, since they don't depend on the network. Projects
+// are listed for the last (longest) period only to keep the card short.
+func toTokenViews(periods []provider.TokenPeriod) []tokenView {
+	var views []tokenView
+	for i, p := range periods {
+		tv := tokenView{
+			Title: p.Label,
+			Total: projects.FormatTokens(p.Total()),
+			Detail: fmt.Sprintf("in %s · out %s · cache %s",
+				projects.FormatTokens(p.Input), projects.FormatTokens(p.Output), projects.FormatTokens(p.CacheWrite+p.CacheRead)),
+		}
+		for j, m := range p.Models {
+			if j == tokenViewModels {
+				break
+			}
+			tv.Models = append(tv.Models, tokenRowView{Label: m.Label, Total: projects.FormatTokens(m.Total())})
+		}
+		if i == len(periods)-1 {
+			for j, pr := range p.Projects {
+				if j == tokenViewModels {
+					break
+				}
+				tv.Projects = append(tv.Projects, tokenRowView{Label: projects.ShortenHome(pr.Label), Total: projects.FormatTokens(pr.Total())})
+			}
 		}
+		views = append(views, tv)
 	}
+	return views
+}
 
-	v.NoData = len(v.Windows) == 0 && len(v.Spend) == 0 && !v.ShowCredits
+// toProjectsView summarizes the top projects for the popup, if any.
+func toProjectsView(rep *projects.Report) *projectsView {
+	if rep == nil || len(rep.Projects) == 0 {
+		return nil
+	}
+
+	v := &projectsView{Title: rep.Period}
+	for i, u := range rep.Projects {
+		if i == popupProjects {
+			break
+		}
+		v.Rows = append(v.Rows, projectRowView{
+			Label:  u.Name,
+			Total:  projects.FormatTokens(u.Total()),
+			Cost:   projects.FormatCost(u),
+			Detail: strings.Join(u.Tools, " · "),
+		})
+	}
 	return v
 }
 
+// sourceText describes non-live data, e.g. "offline · from session log,
+// 12m old".
+func sourceText(source string, asOf time.Time) string {
+	if source == "" {
+		return ""
+	}
+	if asOf.IsZero() {
+		return "offline · from " + source
+	}
+	return fmt.Sprintf("offline · from %s, %s old", source, formatDuration(time.Since(asOf)))
+}
+
+func toKeyView(k provider.KeyUsage) keyView {
+	kv := keyView{
+		Label:    k.Label,
+		Color:    colorForClass(k.Class),
+		Daily:    k.Daily,
+		Weekly:   k.Weekly,
+		Monthly:  k.Monthly,
+		Disabled: k.Disabled,
+	}
+
+	switch {
+	case k.Limit != nil && k.Remaining != nil:
+		kv.Limit = fmt.Sprintf("$%.2f of $%.2f left", *k.Remaining, *k.Limit)
+	case k.Limit != nil:
+		kv.Limit = fmt.Sprintf("limit $%.2f", *k.Limit)
+	default:
+		kv.Limit = "no limit"
+	}
+
+	return kv
+}
+
 func providerClass(name string) string {
 	return strings.ToLower(strings.ReplaceAll(name, " ", ""))
 }
@@ -296,6 +505,17 @@ func colorForPct(pct float64) string {
 	}
 }
 
+func colorForClass(class string) string {
+	switch class {
+	case "critical":
+		return "#e78284"
+	case "warning":
+		return "#e5c890"
+	default:
+		return "#c6d0f5"
+	}
+}
+
 func formatDuration(d time.Duration) string {
 	if d <= 0 {
 		return "now"
@@ -314,14 +534,17 @@ func formatDuration(d time.Duration) string {
 	}
 }
 
-func popupSize(results []provider.Result) (int, int) {
+func popupSize(results []provider.Result, rep *projects.Report) (int, int) {
 	const width = 560
 
 	height := 92
 	for _, r := range results {
 		rows := 1
-		if r.Error == nil {
-			rows = len(r.Windows) + len(r.Spend)
+		if r.Error != nil {
+			v := toProviderView(r)
+			rows += len(v.Windows) + len(v.Spend)
+		} else {
+			rows = len(r.Windows) + len(r.Spend) + 2*len(r.Keys) + len(r.Breakdown) + len(toBreakdownViews(r.Breakdown))
 			if r.Credits != nil {
 				rows++
 			}
@@ -329,9 +552,15 @@ func popupSize(results []provider.Result) (int, int) {
 				rows = 1
 			}
 		}
+		for _, t := range toTokenViews(r.Tokens) {
+			rows += 2 + len(t.Models) + len(t.Projects)
+		}
 
 		height += 62 + rows*22
 	}
+	if v := toProjectsView(rep); v != nil {
+		height += 62 + len(v.Rows)*22
+	}
 
 	if height < 300 {
 		height = 300
@@ -6,7 +6,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jhartzell/ai-usage-bar/internal/projects"
 	"github.com/jhartzell/ai-usage-bar/internal/provider"
+	"github.com/jhartzell/ai-usage-bar/internal/transcripts"
 )
 
 func TestRenderHTMLEscapesUserFields(t *testing.T) {
@@ -18,7 +20,7 @@ func TestRenderHTMLEscapesUserFields(t *testing.T) {
 		},
 	}
 
-	html := renderHTML(results)
+	html := renderHTML(results, nil)
 
 	if strings.Contains(html, `<img src=x onerror=alert(1)>`) {
 		t.Fatal("expected provider name to be escaped")
@@ -32,7 +34,7 @@ func TestRenderHTMLEscapesUserFields(t *testing.T) {
 }
 
 func TestRenderHTMLShowsRecoverButtonWhenAuthErrorPresent(t *testing.T) {
-	html := renderHTML([]provider.Result{{Name: "Claude", Short: "!", Error: errors.New("auth expired")}})
+	html := renderHTML([]provider.Result{{Name: "Claude", Short: "!", Error: errors.New("auth expired")}}, nil)
 	if !strings.Contains(html, "ai-usage-bar://recover-auth") {
 		t.Fatalf("expected recover-auth link in HTML")
 	}
@@ -99,8 +101,86 @@ func TestToProviderViewErrorShortCircuitsDataRows(t *testing.T) {
 	}
 }
 
+func TestToProviderViewShowsSnapshotWindowsWithError(t *testing.T) {
+	v := toProviderView(provider.Result{
+		Name:    "Codex",
+		Error:   errors.New("codex auth expired"),
+		Source:  "session log",
+		AsOf:    time.Now().Add(-5 * time.Minute),
+		Windows: []provider.RateWindow{{Label: "Session (5h)", UsedPct: 80}},
+	})
+
+	if v.Error == "" || v.Source == "" {
+		t.Fatalf("expected error and source, got %#v", v)
+	}
+	if len(v.Windows) != 1 || v.Windows[0].UsedPct != 80 {
+		t.Fatalf("expected the snapshot window next to the error, got %#v", v.Windows)
+	}
+}
+
+func TestToProviderViewShowsEstimatesWithError(t *testing.T) {
+	v := toProviderView(provider.Result{
+		Name:  "Claude",
+		Error: errors.New("offline"),
+		Spend: []provider.SpendEntry{
+			{Label: "Today", Amount: 3},
+			{Label: "Today (API est.)", Amount: 4.5, Estimate: true},
+		},
+	})
+
+	if len(v.Spend) != 1 || !v.Spend[0].Estimate || v.Spend[0].Amount != 4.5 {
+		t.Fatalf("expected only the estimate next to the error, got %#v", v.Spend)
+	}
+}
+
+func TestToProviderViewShowsTokensDespiteError(t *testing.T) {
+	v := toProviderView(provider.Result{
+		Name:  "Claude",
+		Error: errors.New("offline"),
+		Tokens: []provider.TokenPeriod{{
+			Label:      "Today",
+			TokenCount: provider.TokenCount{Input: 1_000, Output: 500, CacheWrite: 2_000, CacheRead: 1_500_000},
+			Models: []provider.TokenRow{
+				{Label: "a", TokenCount: provider.TokenCount{Output: 4}},
+				{Label: "b", TokenCount: provider.TokenCount{Output: 3}},
+				{Label: "c", TokenCount: provider.TokenCount{Output: 2}},
+				{Label: "d", TokenCount: provider.TokenCount{Output: 1}},
+			},
+		}},
+	})
+
+	if len(v.Tokens) != 1 {
+		t.Fatalf("expected token period despite error, got %#v", v.Tokens)
+	}
+	tv := v.Tokens[0]
+	if tv.Total != "1.5M" || tv.Detail != "in 1.0k · out 500 · cache 1.5M" {
+		t.Fatalf("unexpected token text: %q / %q", tv.Total, tv.Detail)
+	}
+	if len(tv.Models) != tokenViewModels {
+		t.Fatalf("expected %d model rows, got %d", tokenViewModels, len(tv.Models))
+	}
+}
+
+func TestToTokenViewsListsProjectsForLastPeriod(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	project := []provider.TokenRow{{Label: home + "/src/api", TokenCount: provider.TokenCount{Input: 1}}}
+	views := toTokenViews([]provider.TokenPeriod{
+		{Label: "Today", Projects: project},
+		{Label: "Last 7 days", Projects: project},
+	})
+
+	if len(views[0].Projects) != 0 {
+		t.Fatalf("expected no project rows for earlier periods, got %#v", views[0].Projects)
+	}
+	if len(views[1].Projects) != 1 || views[1].Projects[0].Label != "~/src/api" {
+		t.Fatalf("unexpected project rows: %#v", views[1].Projects)
+	}
+}
+
 func TestPopupSizeBounds(t *testing.T) {
-	width, height := popupSize(nil)
+	width, height := popupSize(nil, nil)
 	if width != 560 {
 		t.Fatalf("unexpected width: %d", width)
 	}
@@ -117,7 +197,7 @@ func TestPopupSizeBounds(t *testing.T) {
 		large = append(large, provider.Result{Name: "P", Windows: windows})
 	}
 
-	_, capped := popupSize(large)
+	_, capped := popupSize(large, nil)
 	if capped != 760 {
 		t.Fatalf("expected capped height 760, got %d", capped)
 	}
@@ -165,3 +245,78 @@ func TestProviderClassAndClampPct(t *testing.T) {
 		t.Fatalf("expected clamp high to 100, got %v", got)
 	}
 }
+
+func TestToProviderViewKeysHighlightsClass(t *testing.T) {
+	limit, remaining := 10.0, 1.0
+	v := toProviderView(provider.Result{
+		Name: "OpenRouter",
+		Keys: []provider.KeyUsage{
+			{Label: "agents", Limit: &limit, Remaining: &remaining, Monthly: 9, Class: "critical"},
+			{Label: "scratch", Class: "normal"},
+		},
+	})
+
+	if len(v.Keys) != 2 {
+		t.Fatalf("expected 2 keys, got %d", len(v.Keys))
+	}
+	if v.Keys[0].Color != "#e78284" {
+		t.Fatalf("expected critical key color, got %q", v.Keys[0].Color)
+	}
+	if v.Keys[0].Limit != "$1.00 of $10.00 left" {
+		t.Fatalf("unexpected key limit text: %q", v.Keys[0].Limit)
+	}
+	if v.Keys[1].Limit != "no limit" {
+		t.Fatalf("unexpected unlimited key text: %q", v.Keys[1].Limit)
+	}
+	if v.NoData {
+		t.Fatal("provider with keys should not be marked as NoData")
+	}
+}
+
+func TestToProviderViewGroupsBreakdown(t *testing.T) {
+	v := toProviderView(provider.Result{
+		Name: "OpenRouter",
+		Breakdown: []provider.BreakdownEntry{
+			{Group: "Top models today", Label: "a", Amount: 1},
+			{Group: "Top models (30d)", Label: "b", Amount: 2, Requests: 5, Tokens: 1_250_000},
+			{Group: "Top models today", Label: "c", Amount: 0.5},
+		},
+	})
+
+	if len(v.Breakdown) != 2 {
+		t.Fatalf("expected 2 breakdown groups, got %#v", v.Breakdown)
+	}
+	if v.Breakdown[0].Title != "Top models today" || len(v.Breakdown[0].Rows) != 2 {
+		t.Fatalf("unexpected first group: %#v", v.Breakdown[0])
+	}
+	if v.Breakdown[1].Rows[0].Detail != "5 req · 1.2M tok" {
+		t.Fatalf("unexpected second group: %#v", v.Breakdown[1])
+	}
+}
+
+func TestRenderHTMLShowsProjects(t *testing.T) {
+	rep := &projects.Report{Period: "Last 7 days"}
+	for i := 0; i < popupProjects+2; i++ {
+		rep.Projects = append(rep.Projects, projects.Usage{
+			Name:   "<client>",
+			Tools:  []string{"Claude", "Codex"},
+			Tokens: transcripts.Tokens{Input: 2_500_000},
+			Cost:   7.5,
+		})
+	}
+
+	html := renderHTML(nil, rep)
+	if !strings.Contains(html, "Projects") || !strings.Contains(html, "Last 7 days") {
+		t.Fatalf("expected projects section, got: %s", html)
+	}
+	if strings.Contains(html, "<client>") {
+		t.Fatal("expected project name to be escaped")
+	}
+	if got := strings.Count(html, "2.5M · ~$7.50"); got != popupProjects {
+		t.Fatalf("expected %d project rows, got %d", popupProjects, got)
+	}
+
+	if strings.Contains(renderHTML(nil, &projects.Report{Period: "Today"}), "provider projects") {
+		t.Fatal("expected no projects section without usage")
+	}
+}
@@ -0,0 +1,230 @@
+// Package diag writes diagnostic logs: to stderr with --verbose, and to an
+// opt-in, size-rotated file configured under "log" in the config file.
+// Every line is redacted first, so bearer tokens, refresh tokens and API
+// keys never reach either.
+package diag
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxSize is the log file size at which it is rotated.
+const DefaultMaxSize = 1 << 20
+
+// minSecretLen keeps short values such as "1" or "true" in secret-looking
+// environment variables from being scrubbed out of every line.
+const minSecretLen = 8
+
+var logger struct {
+	sync.Mutex
+	writers []io.Writer
+	file    *rotatingFile
+	secrets []string
+}
+
+// Enable sends log lines to w, in addition to any log file.
+func Enable(w io.Writer) {
+	logger.Lock()
+	defer logger.Unlock()
+	logger.writers = append(logger.writers, w)
+	addEnvSecrets()
+}
+
+// OpenFile appends log lines to path, moving it to path+".1" once it
+// exceeds maxSize bytes (DefaultMaxSize when zero or less).
+func OpenFile(path string, maxSize int64) error {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	f, err := openRotating(path, maxSize)
+	if err != nil {
+		return err
+	}
+
+	logger.Lock()
+	defer logger.Unlock()
+	if logger.file != nil {
+		logger.file.Close()
+	}
+	logger.file = f
+	addEnvSecrets()
+	return nil
+}
+
+// Close closes the log file and stops all logging.
+func Close() {
+	logger.Lock()
+	defer logger.Unlock()
+	if logger.file != nil {
+		logger.file.Close()
+	}
+	logger.file, logger.writers = nil, nil
+}
+
+// Enabled reports whether anything is being logged, so callers can skip
+// building expensive messages.
+func Enabled() bool {
+	logger.Lock()
+	defer logger.Unlock()
+	return logger.file != nil || len(logger.writers) > 0
+}
+
+// Logf writes one redacted, timestamped line.
+func Logf(format string, args ...any) {
+	logger.Lock()
+	defer logger.Unlock()
+	if logger.file == nil && len(logger.writers) == 0 {
+		return
+	}
+
+	msg := redact(fmt.Sprintf(format, args...), logger.secrets)
+	line := time.Now().Format("2006-01-02 15:04:05.000") + " " + strings.ReplaceAll(msg, "\n", `\n`) + "\n"
+	for _, w := range logger.writers {
+		io.WriteString(w, line)
+	}
+	if logger.file != nil {
+		io.WriteString(logger.file, line)
+	}
+}
+
+// AddSecret makes s redacted wherever it appears, for credentials that
+// don't look like one, such as a header value read from a secret file.
+func AddSecret(s string) {
+	if len(s) < minSecretLen {
+		return
+	}
+	logger.Lock()
+	defer logger.Unlock()
+	addSecret(s)
+}
+
+func addSecret(s string) {
+	for _, have := range logger.secrets {
+		if have == s {
+			return
+		}
+	}
+	logger.secrets = append(logger.secrets, s)
+	// Longest first, so a secret containing another is replaced whole.
+	sort.Slice(logger.secrets, func(i, j int) bool { return len(logger.secrets[i]) > len(logger.secrets[j]) })
+}
+
+// addEnvSecrets registers the values of variables named like credentials,
+// e.g. OPENROUTER_API_KEY or CURSOR_SESSION_TOKEN.
+func addEnvSecrets() {
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		if len(value) < minSecretLen {
+			continue
+		}
+		for _, suffix := range []string{"_KEY", "_TOKEN", "_SECRET", "_PASSWORD"} {
+			if strings.HasSuffix(name, suffix) {
+				addSecret(value)
+				break
+			}
+		}
+	}
+}
+
+const redacted = "[REDACTED]"
+
+var secretPatterns = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	// Authorization headers, however they were quoted.
+	{regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]+`), "$1 " + redacted},
+	// JSON fields holding credentials, e.g. token refresh responses.
+	{regexp.MustCompile(`(?i)("(?:access_token|refresh_token|id_token|api_key|apikey|secret|client_secret|password|key|token)"\s*:\s*)"[^"]*"`), `$1"` + redacted + `"`},
+	// Credentials in query strings and form bodies.
+	{regexp.MustCompile(`(?i)\b((?:access_token|refresh_token|id_token|api_key|key|token|client_secret)=)[^&\s"]+`), "$1" + redacted},
+	// Well-known key formats and JWTs that turn up anywhere else.
+	{regexp.MustCompile(`\b(?:sk|rk|pk)-[A-Za-z0-9_-]{16,}`), redacted},
+	{regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{20,}`), redacted},
+	{regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{30,}`), redacted},
+	{regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`), redacted},
+}
+
+// Redact removes credentials from s: known token formats, credential
+// fields in JSON and query strings, and any registered secret values.
+func Redact(s string) string {
+	logger.Lock()
+	defer logger.Unlock()
+	return redact(s, logger.secrets)
+}
+
+func redact(s string, secrets []string) string {
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	for _, p := range secretPatterns {
+		s = p.re.ReplaceAllString(s, p.repl)
+	}
+	return s
+}
+
+// Truncate shortens s to at most n bytes for logging response bodies.
+func Truncate(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + fmt.Sprintf("… (%d bytes)", len(s))
+}
+
+// rotatingFile is an append-only file that is moved aside to path+".1",
+// replacing any older backup, when a write would take it past max bytes.
+type rotatingFile struct {
+	path string
+	max  int64
+	f    *os.File
+	size int64
+}
+
+func openRotating(path string, max int64) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, max: max}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size = f, info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	if r.size > 0 && r.size+int64(len(p)) > r.max {
+		r.f.Close()
+		if err := os.Rename(r.path, r.path+".1"); err != nil && !os.IsNotExist(err) {
+			return 0, err
+		}
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) Close() error {
+	return r.f.Close()
+}
@@ -0,0 +1,193 @@
+// Package doctor checks the configuration and that every provider's
+// endpoint is reachable with the configured network settings.
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jhartzell/ai-usage-bar/internal/config"
+	"github.com/jhartzell/ai-usage-bar/internal/provider"
+)
+
+// pingTimeout bounds each reachability check, matching the per-provider
+// fetch timeout.
+const pingTimeout = 5 * time.Second
+
+// check is one line of the report.
+type check struct {
+	name   string
+	ok     bool
+	warn   bool // passed, but worth pointing out
+	detail string
+	hint   string
+}
+
+// Run prints the checks to w and reports whether all of them passed.
+func Run(ctx context.Context, w io.Writer) bool {
+	checks := configChecks()
+	checks = append(checks, reachabilityChecks(ctx, provider.Default())...)
+	return write(w, checks)
+}
+
+// configChecks describes the config file and network settings when they
+// load; when they don't, Default reports the error as a provider.
+func configChecks() []check {
+	path, _ := config.Path()
+	cfg, err := config.Load()
+	if err != nil {
+		return nil
+	}
+
+	detail := path
+	if _, statErr := os.Stat(path); statErr != nil {
+		detail = path + " (not present, using defaults)"
+	}
+	checks := []check{{name: "config", ok: true, detail: detail}}
+
+	if err := provider.ConfigureNetwork(cfg.Network); err == nil {
+		checks = append(checks, check{name: "network", ok: true, detail: describeNetwork(cfg.Network)})
+	}
+	if err := provider.ConfigureEndpoints(cfg.Endpoints); err == nil {
+		checks = append(checks, endpointChecks(provider.EndpointOverrides())...)
+	}
+	return checks
+}
+
+// endpointChecks warns about every endpoint that no longer points at the
+// vendor, since a stale override is easy to forget about.
+func endpointChecks(overrides []provider.EndpointOverride) []check {
+	checks := make([]check, 0, len(overrides))
+	for _, o := range overrides {
+		checks = append(checks, check{
+			name:   "endpoint " + o.Key,
+			ok:     true,
+			warn:   true,
+			detail: fmt.Sprintf("overridden to %s (%s)", redact(o.URL), o.Source),
+		})
+	}
+	return checks
+}
+
+func describeNetwork(n config.Network) string {
+	var parts []string
+	switch n.Proxy {
+	case "":
+		parts = append(parts, "proxy from environment")
+	case "direct":
+		parts = append(parts, "no proxy")
+	default:
+		parts = append(parts, "proxy "+redact(n.Proxy))
+	}
+	if len(n.CAFiles) > 0 {
+		parts = append(parts, fmt.Sprintf("%d extra CA file(s)", len(n.CAFiles)))
+	}
+	if n.ConnectTimeout != "" {
+		parts = append(parts, "connect timeout "+n.ConnectTimeout)
+	}
+	if n.ReadTimeout != "" {
+		parts = append(parts, "read timeout "+n.ReadTimeout)
+	}
+	if len(n.Providers) > 0 {
+		parts = append(parts, fmt.Sprintf("%d provider override(s)", len(n.Providers)))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// redact hides credentials in a proxy or endpoint URL.
+func redact(proxy string) string {
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return proxy
+	}
+	return u.Redacted()
+}
+
+func reachabilityChecks(ctx context.Context, providers []provider.Provider) []check {
+	checks := make([]check, len(providers))
+	var wg sync.WaitGroup
+	for i, p := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+			defer cancel()
+			checks[i] = reachability(p, provider.Ping(pingCtx, p))
+		}()
+	}
+	wg.Wait()
+	return checks
+}
+
+func reachability(p provider.Provider, res provider.PingResult) check {
+	c := check{name: p.Name()}
+	// Config problems, including a broken config file or network section,
+	// reach Default as placeholder providers.
+	if ce, ok := p.(interface{ ConfigErr() error }); ok {
+		c.detail = ce.ConfigErr().Error()
+		return c
+	}
+	if res.URL == "" {
+		c.ok = res.Err == nil
+		c.detail = "no HTTP endpoint to check"
+		if res.Err != nil {
+			c.detail = res.Err.Error()
+		}
+		return c
+	}
+
+	host := res.URL
+	if u, err := url.Parse(res.URL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	via := ""
+	if res.Proxy != "" {
+		via = " via " + res.Proxy
+	}
+
+	if res.Err != nil {
+		err := res.Err
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err // the URL is already named by host
+		}
+		c.detail = fmt.Sprintf("%s%s: %v", host, via, err)
+		if provider.IsCertificateError(res.Err) {
+			c.hint = "add your proxy's CA bundle to network.ca_files in the config file"
+		}
+		return c
+	}
+
+	c.ok = true
+	c.detail = fmt.Sprintf("%s%s reachable (HTTP %d, %s)", host, via, res.Status, res.Elapsed.Round(time.Millisecond))
+	return c
+}
+
+func write(w io.Writer, checks []check) bool {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	allOK := true
+	for _, c := range checks {
+		status := "ok"
+		if c.warn {
+			status = "WARN"
+		}
+		if !c.ok {
+			status = "FAIL"
+			allOK = false
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", status, c.name, c.detail)
+		if c.hint != "" {
+			fmt.Fprintf(tw, "\t\thint: %s\n", c.hint)
+		}
+	}
+	tw.Flush()
+	return allOK
+}
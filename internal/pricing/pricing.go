@@ -0,0 +1,84 @@
+// Package pricing holds per-model API list prices used to estimate what
+// locally counted token usage would have cost on pay-as-you-go billing.
+package pricing
+
+import "strings"
+
+// Price is a model's USD price per million tokens.
+type Price struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheWrite float64 `json:"cache_write"`
+	CacheRead  float64 `json:"cache_read"`
+}
+
+// Cost returns the USD cost of the given token counts.
+func (p Price) Cost(input, output, cacheWrite, cacheRead int64) float64 {
+	return (float64(input)*p.Input +
+		float64(output)*p.Output +
+		float64(cacheWrite)*p.CacheWrite +
+		float64(cacheRead)*p.CacheRead) / 1e6
+}
+
+// Table maps model names, or name prefixes, to prices.
+type Table map[string]Price
+
+// Default returns the built-in list prices. Claude cache writes use the
+// 5-minute cache rate; OpenAI has no separate cache-write charge, and
+// o3-pro has no cached-input discount. Models priced differently from the
+// family they share a prefix with need their own entry.
+func Default() Table {
+	return Table{
+		"claude-opus-4-6":   {Input: 5, Output: 25, CacheWrite: 6.25, CacheRead: 0.50},
+		"claude-opus-4-5":   {Input: 5, Output: 25, CacheWrite: 6.25, CacheRead: 0.50},
+		"claude-opus-4":     {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.50},
+		"claude-sonnet-4":   {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30},
+		"claude-3-7-sonnet": {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30},
+		"claude-3-5-sonnet": {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30},
+		"claude-haiku-4-5":  {Input: 1, Output: 5, CacheWrite: 1.25, CacheRead: 0.10},
+		"claude-3-5-haiku":  {Input: 0.80, Output: 4, CacheWrite: 1, CacheRead: 0.08},
+
+		"gpt-5":             {Input: 1.25, Output: 10, CacheRead: 0.125},
+		"gpt-5-mini":        {Input: 0.25, Output: 2, CacheRead: 0.025},
+		"gpt-5-nano":        {Input: 0.05, Output: 0.40, CacheRead: 0.005},
+		"gpt-4.1":           {Input: 2, Output: 8, CacheRead: 0.50},
+		"gpt-4.1-mini":      {Input: 0.40, Output: 1.60, CacheRead: 0.10},
+		"o3":                {Input: 2, Output: 8, CacheRead: 0.50},
+		"o3-pro":            {Input: 20, Output: 80, CacheRead: 20},
+		"o3-mini":           {Input: 1.10, Output: 4.40, CacheRead: 0.55},
+		"o4-mini":           {Input: 1.10, Output: 4.40, CacheRead: 0.275},
+		"codex-mini-latest": {Input: 1.50, Output: 6, CacheRead: 0.375},
+	}
+}
+
+// With returns a copy of t with overrides applied on top.
+func (t Table) With(overrides map[string]Price) Table {
+	out := make(Table, len(t)+len(overrides))
+	for k, v := range t {
+		out[k] = v
+	}
+	for k, v := range overrides {
+		out[k] = v
+	}
+	return out
+}
+
+// Lookup finds the price for model: an exact match, else the longest key
+// that is a prefix of it, so "claude-sonnet-4-5-20250929" matches
+// "claude-sonnet-4" and "gpt-5.1-codex" matches "gpt-5".
+func (t Table) Lookup(model string) (Price, bool) {
+	if p, ok := t[model]; ok {
+		return p, true
+	}
+
+	best := ""
+	for k := range t {
+		if len(k) > len(best) && strings.HasPrefix(model, k) {
+			best = k
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return t[best], true
+}
@@ -0,0 +1,62 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLookupPrefersLongestPrefix(t *testing.T) {
+	table := Default()
+
+	tests := []struct {
+		model string
+		want  float64 // input price
+		ok    bool
+	}{
+		{model: "claude-opus-4-5-20251101", want: 5, ok: true},
+		{model: "claude-opus-4-5", want: 5, ok: true},
+		{model: "claude-opus-4-6", want: 5, ok: true},
+		{model: "claude-opus-4-1-20250805", want: 15, ok: true},
+		{model: "claude-sonnet-4-5-20250929", want: 3, ok: true},
+		{model: "gpt-5-mini-2025-08-07", want: 0.25, ok: true},
+		{model: "gpt-5-codex", want: 1.25, ok: true},
+		{model: "o3-mini", want: 1.10, ok: true},
+		{model: "o3-pro", want: 20, ok: true},
+		{model: "o3-pro-2025-06-10", want: 20, ok: true},
+		{model: "o3-2025-04-16", want: 2, ok: true},
+		{model: "mystery-model", ok: false},
+	}
+	for _, tt := range tests {
+		p, ok := table.Lookup(tt.model)
+		if ok != tt.ok || p.Input != tt.want {
+			t.Fatalf("Lookup(%q) = %+v, %v; want input %v, %v", tt.model, p, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestWithOverridesAndExtends(t *testing.T) {
+	base := Default()
+	table := base.With(map[string]Price{
+		"claude-sonnet-4": {Input: 1, Output: 2},
+		"internal-llm":    {Input: 0.5, Output: 0.5},
+	})
+
+	if p, _ := table.Lookup("claude-sonnet-4-5"); p.Input != 1 {
+		t.Fatalf("expected override to win, got %+v", p)
+	}
+	if _, ok := table.Lookup("internal-llm-v2"); !ok {
+		t.Fatal("expected added model to be found")
+	}
+	if p, _ := base.Lookup("claude-sonnet-4"); p.Input != 3 {
+		t.Fatalf("expected base table to be unchanged, got %+v", p)
+	}
+}
+
+func TestCost(t *testing.T) {
+	p := Price{Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30}
+	got := p.Cost(1_000_000, 100_000, 200_000, 10_000_000)
+	want := 3 + 1.5 + 0.75 + 3.0
+	if math.Abs(got-want) > 1e-9 {
+		t.Fatalf("Cost = %v, want %v", got, want)
+	}
+}
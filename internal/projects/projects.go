@@ -0,0 +1,313 @@
+// Package projects attributes locally counted token usage, and its
+// API-equivalent cost, to the repositories it was spent in.
+package projects
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jhartzell/ai-usage-bar/internal/config"
+	"github.com/jhartzell/ai-usage-bar/internal/pricing"
+	"github.com/jhartzell/ai-usage-bar/internal/transcripts"
+)
+
+// DefaultPeriod is used when neither the command line nor the config file
+// picks one.
+const DefaultPeriod = "week"
+
+// unknownProject labels usage logged without a working directory.
+const unknownProject = "(unknown)"
+
+// Usage is the token usage attributed to one project.
+type Usage struct {
+	Name  string
+	Tools []string // which CLIs the usage came from, e.g. Claude, Codex
+	transcripts.Tokens
+	Cost float64 // USD, at API list prices
+	// Unpriced counts tokens from models without a known price, which are
+	// missing from Cost.
+	Unpriced int64
+}
+
+// Report is project usage over one period, sorted by tokens descending.
+type Report struct {
+	Period   string
+	Since    time.Time
+	Projects []Usage
+}
+
+// Source is one CLI's buckets.
+type Source struct {
+	Tool    string
+	Buckets []transcripts.Bucket
+}
+
+// ParsePeriod resolves today, week or month to a period ending now. Weeks
+// and months are the last 7 and 30 calendar days including today.
+func ParsePeriod(name string, now time.Time) (transcripts.Period, error) {
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+
+	switch name {
+	case "today":
+		return transcripts.Period{Label: "Today", Since: today}, nil
+	case "week":
+		return transcripts.Period{Label: "Last 7 days", Since: today.AddDate(0, 0, -6)}, nil
+	case "month":
+		return transcripts.Period{Label: "Last 30 days", Since: today.AddDate(0, 0, -29)}, nil
+	default:
+		return transcripts.Period{}, fmt.Errorf("unknown period %q (want today, week or month)", name)
+	}
+}
+
+// Collect scans the local Claude Code and Codex logs and attributes the
+// usage since the start of period using the config file's aliases and
+// prices. An empty period uses the config file's, else DefaultPeriod.
+func Collect(ctx context.Context, now time.Time, period string) (*Report, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	if period == "" {
+		period = cfg.Projects.Period
+	}
+	if period == "" {
+		period = DefaultPeriod
+	}
+	p, err := ParsePeriod(period, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []Source
+	scanners := []struct {
+		tool string
+		dir  func() (string, error)
+		scan func(string) transcripts.Scanner
+	}{
+		{"Claude", transcripts.ClaudeDir, transcripts.Claude},
+		{"Codex", transcripts.CodexDir, transcripts.Codex},
+	}
+	for _, s := range scanners {
+		dir, err := s.dir()
+		if err != nil {
+			continue
+		}
+		// A state file that can't be saved still leaves usable buckets.
+		buckets, _ := s.scan(dir).Scan(ctx, now)
+		sources = append(sources, Source{Tool: s.tool, Buckets: buckets})
+	}
+
+	table := pricing.Default().With(cfg.Pricing)
+	return &Report{
+		Period:   p.Label,
+		Since:    p.Since,
+		Projects: Attribute(sources, p.Since, cfg.Projects.Aliases, table),
+	}, nil
+}
+
+// Attribute totals the buckets since the given time per project.
+func Attribute(sources []Source, since time.Time, aliases []config.ProjectAlias, table pricing.Table) []Usage {
+	r := newResolver(aliases)
+	byName := map[string]*Usage{}
+	tools := map[string]map[string]bool{}
+
+	for _, src := range sources {
+		for _, b := range src.Buckets {
+			if b.Minute < since.Unix() {
+				continue
+			}
+			name := r.name(b.Project)
+			u := byName[name]
+			if u == nil {
+				u = &Usage{Name: name}
+				byName[name] = u
+				tools[name] = map[string]bool{}
+			}
+			u.Add(b.Tokens)
+			tools[name][src.Tool] = true
+
+			if price, ok := table.Lookup(b.Model); ok {
+				u.Cost += price.Cost(b.Input, b.Output, b.CacheWrite, b.CacheRead)
+			} else {
+				u.Unpriced += b.Total()
+			}
+		}
+	}
+
+	out := make([]Usage, 0, len(byName))
+	for name, u := range byName {
+		for _, src := range sources {
+			if tools[name][src.Tool] {
+				u.Tools = append(u.Tools, src.Tool)
+			}
+		}
+		out = append(out, *u)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Total() != out[j].Total() {
+			return out[i].Total() > out[j].Total()
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+type resolver struct {
+	aliases []config.ProjectAlias
+	home    string
+	names   map[string]string
+}
+
+func newResolver(aliases []config.ProjectAlias) *resolver {
+	r := &resolver{names: map[string]string{}}
+	for _, a := range aliases {
+		r.aliases = append(r.aliases, config.ProjectAlias{Match: filepath.Clean(config.ExpandHome(a.Match)), Name: a.Name})
+	}
+	r.home, _ = os.UserHomeDir()
+	return r
+}
+
+// name returns the first alias matching dir, else the git repository
+// containing it, else dir itself. Results are cached since the same few
+// directories recur across thousands of buckets.
+func (r *resolver) name(dir string) string {
+	if dir == "" {
+		return unknownProject
+	}
+	if name, ok := r.names[dir]; ok {
+		return name
+	}
+
+	name := ""
+	for _, a := range r.aliases {
+		if matchPath(a.Match, dir) {
+			name = a.Name
+			break
+		}
+	}
+	if name == "" {
+		name = r.shorten(repoRoot(dir))
+	}
+	r.names[dir] = name
+	return name
+}
+
+// matchPath reports whether pattern matches path or any of its parents.
+func matchPath(pattern, path string) bool {
+	for p := filepath.Clean(path); ; p = filepath.Dir(p) {
+		if ok, _ := filepath.Match(pattern, p); ok {
+			return true
+		}
+		if filepath.Dir(p) == p {
+			return false
+		}
+	}
+}
+
+// repoRoot returns the top of the git work tree containing dir, mapping a
+// linked worktree back to its main repository. Directories that are not
+// in a repository, or no longer exist, are returned unchanged.
+func repoRoot(dir string) string {
+	for p := filepath.Clean(dir); ; p = filepath.Dir(p) {
+		info, err := os.Stat(filepath.Join(p, ".git"))
+		if err == nil {
+			if !info.IsDir() {
+				if main, ok := worktreeMain(filepath.Join(p, ".git")); ok {
+					return main
+				}
+			}
+			return p
+		}
+		if filepath.Dir(p) == p {
+			return dir
+		}
+	}
+}
+
+// worktreeMain reads a linked worktree's .git file, which points into the
+// main repository's .git/worktrees directory.
+func worktreeMain(gitFile string) (string, bool) {
+	data, err := os.ReadFile(gitFile)
+	if err != nil {
+		return "", false
+	}
+	gitdir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+	if !ok {
+		return "", false
+	}
+	sep := string(filepath.Separator) + ".git" + string(filepath.Separator) + "worktrees" + string(filepath.Separator)
+	i := strings.LastIndex(gitdir, sep)
+	if i < 0 {
+		return "", false // e.g. a submodule, which is its own project
+	}
+	return gitdir[:i], true
+}
+
+func (r *resolver) shorten(path string) string {
+	return shortenHome(r.home, path)
+}
+
+// ShortenHome abbreviates the user's home directory in path to ~.
+func ShortenHome(path string) string {
+	home, _ := os.UserHomeDir()
+	return shortenHome(home, path)
+}
+
+func shortenHome(home, path string) string {
+	if home != "" && (path == home || strings.HasPrefix(path, home+string(filepath.Separator))) {
+		return "~" + strings.TrimPrefix(path, home)
+	}
+	return path
+}
+
+// Write prints the report as a table.
+func Write(w io.Writer, rep *Report) error {
+	fmt.Fprintf(w, "Projects · %s\n\n", rep.Period)
+	if len(rep.Projects) == 0 {
+		_, err := fmt.Fprintln(w, "No local token usage in this period.")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROJECT\tTOKENS\tEST. COST\tTOOLS")
+	var total Usage
+	for _, u := range rep.Projects {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", u.Name, FormatTokens(u.Total()), FormatCost(u), strings.Join(u.Tools, ", "))
+		total.Add(u.Tokens)
+		total.Cost += u.Cost
+		total.Unpriced += u.Unpriced
+	}
+	fmt.Fprintf(tw, "Total\t%s\t%s\t\n", FormatTokens(total.Total()), FormatCost(total))
+	return tw.Flush()
+}
+
+// FormatCost renders an estimated cost, or "-" when none of the usage
+// could be priced.
+func FormatCost(u Usage) string {
+	if u.Cost == 0 && u.Unpriced > 0 {
+		return "-"
+	}
+	return fmt.Sprintf("~$%.2f", u.Cost)
+}
+
+// FormatTokens renders a token count compactly, e.g. 12.3M.
+func FormatTokens(n int64) string {
+	switch {
+	case n >= 1_000_000_000:
+		return fmt.Sprintf("%.1fB", float64(n)/1e9)
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
@@ -0,0 +1,199 @@
+package projects
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jhartzell/ai-usage-bar/internal/config"
+	"github.com/jhartzell/ai-usage-bar/internal/pricing"
+	"github.com/jhartzell/ai-usage-bar/internal/transcripts"
+)
+
+func mkdir(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAttributeGroupsByRepoAndAlias(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	repo := filepath.Join(home, "src", "tool")
+	mkdir(t, filepath.Join(repo, ".git"))
+	mkdir(t, filepath.Join(repo, "cmd"))
+
+	since := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	minute := since.Add(time.Hour).Unix()
+	sources := []Source{
+		{Tool: "Claude", Buckets: []transcripts.Bucket{
+			{Minute: minute, Model: "claude-sonnet-4-5", Project: filepath.Join(repo, "cmd"), Tokens: transcripts.Tokens{Input: 1_000_000}},
+			{Minute: minute, Model: "claude-sonnet-4-5", Project: "/clients/acme/api", Tokens: transcripts.Tokens{Output: 1_000_000}},
+			{Minute: since.Add(-time.Hour).Unix(), Model: "claude-sonnet-4-5", Project: repo, Tokens: transcripts.Tokens{Input: 5}},
+		}},
+		{Tool: "Codex", Buckets: []transcripts.Bucket{
+			{Minute: minute, Model: "gpt-5", Project: repo, Tokens: transcripts.Tokens{Input: 1_000_000}},
+			{Minute: minute, Model: "mystery", Project: "/clients/acme/web", Tokens: transcripts.Tokens{Input: 7}},
+			{Minute: minute, Model: "gpt-5", Tokens: transcripts.Tokens{Input: 3}},
+		}},
+	}
+	aliases := []config.ProjectAlias{{Match: "/clients/acme", Name: "Acme"}}
+	table := pricing.Table{
+		"claude-sonnet-4": {Input: 3, Output: 15},
+		"gpt-5":           {Input: 1.25},
+	}
+
+	got := Attribute(sources, since, aliases, table)
+	if len(got) != 3 {
+		t.Fatalf("expected three projects, got %+v", got)
+	}
+
+	tool := got[0]
+	if tool.Name != "~/src/tool" || tool.Total() != 2_000_000 || strings.Join(tool.Tools, ",") != "Claude,Codex" {
+		t.Fatalf("unexpected repo usage: %+v", tool)
+	}
+	if tool.Cost != 3+1.25 || tool.Unpriced != 0 {
+		t.Fatalf("unexpected repo cost: %+v", tool)
+	}
+
+	acme := got[1]
+	if acme.Name != "Acme" || acme.Total() != 1_000_007 || acme.Cost != 15 || acme.Unpriced != 7 {
+		t.Fatalf("unexpected alias usage: %+v", acme)
+	}
+
+	if got[2].Name != unknownProject || got[2].Total() != 3 {
+		t.Fatalf("expected usage without a directory to be unknown, got %+v", got[2])
+	}
+}
+
+func TestAliasGlobMatchesParents(t *testing.T) {
+	r := newResolver([]config.ProjectAlias{
+		{Match: "/work/*/billing", Name: "Billing"},
+		{Match: "/work/*", Name: "Work"},
+	})
+
+	cases := map[string]string{
+		"/work/acme/billing/src": "Billing",
+		"/work/acme/web":         "Work",
+		"/play/thing":            "/play/thing",
+	}
+	for dir, want := range cases {
+		if got := r.name(dir); got != want {
+			t.Fatalf("%s: got %q want %q", dir, got, want)
+		}
+	}
+}
+
+func TestRepoRootFollowsWorktrees(t *testing.T) {
+	root := t.TempDir()
+	main := filepath.Join(root, "app")
+	mkdir(t, filepath.Join(main, ".git", "worktrees", "feature"))
+
+	wt := filepath.Join(root, "app-feature")
+	mkdir(t, filepath.Join(wt, "pkg"))
+	gitFile := "gitdir: " + filepath.Join(main, ".git", "worktrees", "feature") + "\n"
+	if err := os.WriteFile(filepath.Join(wt, ".git"), []byte(gitFile), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := repoRoot(filepath.Join(wt, "pkg")); got != main {
+		t.Fatalf("got %q want %q", got, main)
+	}
+	if got := repoRoot("/does/not/exist"); got != "/does/not/exist" {
+		t.Fatalf("expected missing directory unchanged, got %q", got)
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+
+	week, err := ParsePeriod("week", now)
+	if err != nil || week.Label != "Last 7 days" || !week.Since.Equal(time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected week: %+v, %v", week, err)
+	}
+	if _, err := ParsePeriod("year", now); err == nil {
+		t.Fatal("expected unknown period error")
+	}
+}
+
+func TestCollectReadsLogsAndConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	cfgDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", cfgDir)
+
+	mkdir(t, filepath.Join(cfgDir, "ai-usage-bar"))
+	cfg := `{"projects":{"period":"today","aliases":[{"match":"~/clients/acme","name":"Acme"}]}}`
+	if err := os.WriteFile(filepath.Join(cfgDir, "ai-usage-bar", "config.json"), []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(home, ".claude", "projects", "-acme")
+	mkdir(t, dir)
+	acme := filepath.Join(home, "clients", "acme")
+	lines := `{"type":"assistant","timestamp":"2026-03-09T12:00:00Z","cwd":"` + acme + `","message":{"id":"m1","model":"claude-sonnet-4-5","usage":{"input_tokens":100}}}
+{"type":"assistant","timestamp":"2026-03-10T12:00:00Z","cwd":"` + acme + `","message":{"id":"m2","model":"claude-sonnet-4-5","usage":{"input_tokens":10,"output_tokens":20}}}
+`
+	if err := os.WriteFile(filepath.Join(dir, "s.jsonl"), []byte(lines), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rep, err := Collect(context.Background(), time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC), "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rep.Period != "Today" || len(rep.Projects) != 1 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+	if u := rep.Projects[0]; u.Name != "Acme" || u.Total() != 30 || u.Cost <= 0 {
+		t.Fatalf("unexpected usage: %+v", u)
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, &Report{Period: "Last 7 days", Projects: []Usage{
+		{Name: "Acme", Tools: []string{"Claude", "Codex"}, Tokens: transcripts.Tokens{Input: 1_500_000}, Cost: 4.5},
+		{Name: "~/src/tool", Tools: []string{"Codex"}, Tokens: transcripts.Tokens{Input: 900}, Unpriced: 900},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"Projects · Last 7 days", "1.5M", "~$4.50", "Claude, Codex", "Total"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output:\n%s", want, out)
+		}
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if f := strings.Fields(line); len(f) > 0 && f[0] == "~/src/tool" && f[2] != "-" {
+			t.Fatalf("expected unpriced project to show no cost, got %q", line)
+		}
+	}
+}
+
+func TestFormatTokens(t *testing.T) {
+	tests := []struct {
+		in   int64
+		want string
+	}{
+		{in: 999, want: "999"},
+		{in: 12_345, want: "12.3k"},
+		{in: 4_500_000, want: "4.5M"},
+		{in: 2_000_000_000, want: "2.0B"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatTokens(tt.in); got != tt.want {
+			t.Fatalf("FormatTokens(%d): got %q want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Helpers shared by the providers that read cost, usage and balance
+// endpoints with an API or organization admin key.
+
+// maxReportPages bounds pagination so a misbehaving cursor can't loop
+// forever. Reports that need more are an error rather than silently short.
+const maxReportPages = 20
+
+// getPages issues GET requests for a paginated admin report or listing,
+// following the cursor returned by handle (sent back as the cursor query
+// parameter) until it is empty. setAuth adds credentials to each request;
+// credential names the key in auth errors.
+func getPages(ctx context.Context, endpoint string, q url.Values, cursor string, setAuth func(*http.Request), credential string, handle func(body []byte) (string, error)) error {
+	for page := 0; page < maxReportPages; page++ {
+		body, err := getBody(ctx, endpoint+"?"+q.Encode(), setAuth, credential)
+		if err != nil {
+			return err
+		}
+
+		next, err := handle(body)
+		if err != nil {
+			return err
+		}
+		if next == "" {
+			return nil
+		}
+		q.Set(cursor, next)
+	}
+	return fmt.Errorf("report truncated after %d pages", maxReportPages)
+}
+
+// getJSON issues a single authenticated GET and decodes the JSON response
+// into out, reporting a rejected key the way getPages does.
+func getJSON(ctx context.Context, endpoint string, setAuth func(*http.Request), credential string, out any) error {
+	body, err := getBody(ctx, endpoint, setAuth, credential)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, out)
+}
+
+func getBody(ctx context.Context, endpoint string, setAuth func(*http.Request), credential string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	setAuth(req)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, adminAuthError{credential: credential, status: resp.StatusCode}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	return readBody(resp)
+}
+
+type adminAuthError struct {
+	credential string
+	status     int
+}
+
+func (e adminAuthError) Error() string {
+	return fmt.Sprintf("%s rejected (HTTP %d)", e.credential, e.status)
+}
+
+// adminShort maps a report error to the bar's short text: "!" when the key
+// was rejected, "?" otherwise.
+func adminShort(err error) string {
+	if _, ok := err.(adminAuthError); ok {
+		return "!"
+	}
+	return "?"
+}
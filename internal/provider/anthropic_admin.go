@@ -0,0 +1,246 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AnthropicAdmin reports Anthropic API (Console) cost using an Admin API key.
+// BaseURL overrides the API host, e.g. to point at a local stub; when empty
+// ANTHROPIC_ADMIN_BASE_URL or the public API is used.
+type AnthropicAdmin struct {
+	BaseURL string
+}
+
+func (AnthropicAdmin) Name() string { return "Anthropic API" }
+
+func (AnthropicAdmin) Configured() bool { return os.Getenv("ANTHROPIC_ADMIN_KEY") != "" }
+
+type anthropicCostReport struct {
+	Data     []anthropicCostBucket `json:"data"`
+	HasMore  bool                  `json:"has_more"`
+	NextPage string                `json:"next_page"`
+}
+
+type anthropicCostBucket struct {
+	StartingAt string                `json:"starting_at"`
+	Results    []anthropicCostResult `json:"results"`
+}
+
+type anthropicCostResult struct {
+	Currency    string  `json:"currency"`
+	Amount      string  `json:"amount"` // decimal string in cents
+	WorkspaceID *string `json:"workspace_id"`
+	Description string  `json:"description"`
+	Model       string  `json:"model"`
+}
+
+type anthropicUsageReport struct {
+	Data     []anthropicUsageBucket `json:"data"`
+	HasMore  bool                   `json:"has_more"`
+	NextPage string                 `json:"next_page"`
+}
+
+type anthropicUsageBucket struct {
+	StartingAt string                 `json:"starting_at"`
+	Results    []anthropicUsageResult `json:"results"`
+}
+
+type anthropicUsageResult struct {
+	Model                string `json:"model"`
+	UncachedInputTokens  int64  `json:"uncached_input_tokens"`
+	CacheReadInputTokens int64  `json:"cache_read_input_tokens"`
+	OutputTokens         int64  `json:"output_tokens"`
+	CacheCreation        struct {
+		Ephemeral1h int64 `json:"ephemeral_1h_input_tokens"`
+		Ephemeral5m int64 `json:"ephemeral_5m_input_tokens"`
+	} `json:"cache_creation"`
+}
+
+type anthropicWorkspacesResponse struct {
+	Data []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"data"`
+	HasMore bool   `json:"has_more"`
+	LastID  string `json:"last_id"`
+}
+
+const (
+	anthropicAdminBaseURL     = "https://api.anthropic.com"
+	anthropicAPIVersion       = "2023-06-01"
+	anthropicDefaultWorkspace = "Default"
+	anthropicTopRows          = 5
+)
+
+func (a AnthropicAdmin) Fetch(ctx context.Context) Result {
+	return a.fetch(ctx, time.Now())
+}
+
+func (a AnthropicAdmin) fetch(ctx context.Context, now time.Time) Result {
+	r := Result{Name: a.Name()}
+
+	key := credential(os.Getenv("ANTHROPIC_ADMIN_KEY"))
+	if key == "" {
+		r.Error = fmt.Errorf("ANTHROPIC_ADMIN_KEY not set")
+		r.Short = "?"
+		return r
+	}
+
+	p := newSpendPeriods(now)
+	start := p.start().Format(time.RFC3339)
+
+	q := url.Values{}
+	q.Set("starting_at", start)
+	q.Set("bucket_width", "1d")
+	q.Add("group_by[]", "workspace_id")
+	q.Add("group_by[]", "description")
+
+	var costs []anthropicCostBucket
+	err := a.getPages(ctx, key, "/v1/organizations/cost_report", q, "page", func(body []byte) (string, error) {
+		var page anthropicCostReport
+		if err := json.Unmarshal(body, &page); err != nil {
+			return "", err
+		}
+		costs = append(costs, page.Data...)
+		if !page.HasMore {
+			return "", nil
+		}
+		return page.NextPage, nil
+	})
+	if err != nil {
+		r.Error = err
+		r.Short = adminShort(err)
+		return r
+	}
+
+	workspaces := a.workspaceNames(ctx, key)
+	totals := map[string]float64{}
+	byWorkspace := map[string]map[string]*BreakdownEntry{}
+	byModel := map[string]map[string]*BreakdownEntry{}
+
+	for _, bucket := range costs {
+		t, err := time.Parse(time.RFC3339, bucket.StartingAt)
+		if err != nil {
+			continue
+		}
+		for _, c := range bucket.Results {
+			cents, err := strconv.ParseFloat(c.Amount, 64)
+			if err != nil {
+				continue
+			}
+			amount := cents / 100
+
+			workspace := anthropicDefaultWorkspace
+			if c.WorkspaceID != nil && *c.WorkspaceID != "" {
+				workspace = *c.WorkspaceID
+				if name, ok := workspaces[workspace]; ok {
+					workspace = name
+				}
+			}
+			model := c.Model
+			if model == "" {
+				model = c.Description
+			}
+			if model == "" {
+				model = "other"
+			}
+
+			for _, period := range p.containing(t) {
+				totals[period] += amount
+				addBreakdown(byWorkspace, period+" by workspace", workspace, amount, 0)
+				addBreakdown(byModel, period+" by model", model, amount, 0)
+			}
+		}
+	}
+
+	a.addModelTokens(ctx, key, p, byModel)
+
+	for _, period := range spendPeriodLabels {
+		r.Spend = append(r.Spend, SpendEntry{Label: period, Amount: totals[period]})
+	}
+	for _, period := range spendPeriodLabels {
+		r.Breakdown = append(r.Breakdown, topBreakdown(byWorkspace[period+" by workspace"], anthropicTopRows)...)
+		r.Breakdown = append(r.Breakdown, topBreakdown(byModel[period+" by model"], anthropicTopRows)...)
+	}
+
+	r.Short = fmt.Sprintf("$%.2f", totals["This month"])
+	r.Class = "normal"
+	return r
+}
+
+// addModelTokens merges per-model token volume from the usage report into
+// the monthly model rows. It is best-effort: cost data alone is still useful.
+func (a AnthropicAdmin) addModelTokens(ctx context.Context, key string, p spendPeriods, byModel map[string]map[string]*BreakdownEntry) {
+	q := url.Values{}
+	q.Set("starting_at", p.monthStart.Format(time.RFC3339))
+	q.Set("bucket_width", "1d")
+	q.Add("group_by[]", "model")
+
+	_ = a.getPages(ctx, key, "/v1/organizations/usage_report/messages", q, "page", func(body []byte) (string, error) {
+		var page anthropicUsageReport
+		if err := json.Unmarshal(body, &page); err != nil {
+			return "", err
+		}
+		for _, bucket := range page.Data {
+			for _, u := range bucket.Results {
+				if u.Model == "" {
+					continue
+				}
+				tokens := u.UncachedInputTokens + u.CacheReadInputTokens + u.OutputTokens +
+					u.CacheCreation.Ephemeral1h + u.CacheCreation.Ephemeral5m
+				addBreakdown(byModel, "This month by model", u.Model, 0, tokens)
+			}
+		}
+		if !page.HasMore {
+			return "", nil
+		}
+		return page.NextPage, nil
+	})
+}
+
+func (a AnthropicAdmin) workspaceNames(ctx context.Context, key string) map[string]string {
+	names := map[string]string{}
+	q := url.Values{}
+	q.Set("limit", "100")
+
+	_ = a.getPages(ctx, key, "/v1/organizations/workspaces", q, "after_id", func(body []byte) (string, error) {
+		var page anthropicWorkspacesResponse
+		if err := json.Unmarshal(body, &page); err != nil {
+			return "", err
+		}
+		for _, w := range page.Data {
+			names[w.ID] = w.Name
+		}
+		if !page.HasMore {
+			return "", nil
+		}
+		return page.LastID, nil
+	})
+	return names
+}
+
+func (a AnthropicAdmin) getPages(ctx context.Context, key, path string, q url.Values, cursor string, handle func(body []byte) (string, error)) error {
+	return getPages(ctx, a.baseURL()+path, q, cursor, func(req *http.Request) {
+		req.Header.Set("x-api-key", key)
+		req.Header.Set("anthropic-version", anthropicAPIVersion)
+	}, "anthropic admin key", handle)
+}
+
+func (a AnthropicAdmin) baseURL() string {
+	base := a.BaseURL
+	if base == "" {
+		base = os.Getenv("ANTHROPIC_ADMIN_BASE_URL")
+	}
+	if base == "" {
+		base = endpointURL("anthropic_admin")
+	}
+	return strings.TrimRight(base, "/")
+}
@@ -0,0 +1,173 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAnthropicAdminFetchRequiresKey(t *testing.T) {
+	t.Setenv("ANTHROPIC_ADMIN_KEY", "")
+
+	r := AnthropicAdmin{}.Fetch(context.Background())
+	if r.Error == nil {
+		t.Fatal("expected missing key error")
+	}
+	if r.Short != "?" {
+		t.Fatalf("expected short '?', got %q", r.Short)
+	}
+}
+
+func TestAnthropicAdminFetchAuthFailure(t *testing.T) {
+	t.Setenv("ANTHROPIC_ADMIN_KEY", "sk-ant-admin-test")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	r := AnthropicAdmin{BaseURL: srv.URL}.Fetch(context.Background())
+	if r.Error == nil {
+		t.Fatal("expected auth error")
+	}
+	if r.Short != "!" {
+		t.Fatalf("expected short '!', got %q", r.Short)
+	}
+}
+
+func TestAnthropicAdminFetchCostReport(t *testing.T) {
+	t.Setenv("ANTHROPIC_ADMIN_KEY", "sk-ant-admin-test")
+
+	// Wednesday: the week started Monday 2026-03-02, the month on 2026-03-01.
+	now := time.Date(2026, 3, 4, 15, 0, 0, 0, time.UTC)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("x-api-key") != "sk-ant-admin-test" {
+			t.Fatalf("missing admin key header: %q", req.Header.Get("x-api-key"))
+		}
+		if req.Header.Get("anthropic-version") != anthropicAPIVersion {
+			t.Fatalf("missing version header: %q", req.Header.Get("anthropic-version"))
+		}
+
+		switch req.URL.Path {
+		case "/v1/organizations/cost_report":
+			if req.URL.Query().Get("starting_at") != "2026-03-01T00:00:00Z" {
+				t.Fatalf("unexpected starting_at: %q", req.URL.Query().Get("starting_at"))
+			}
+			if req.URL.Query().Get("page") == "" {
+				_, _ = w.Write([]byte(`{"data":[
+				  {"starting_at":"2026-03-01T00:00:00Z","results":[
+				    {"currency":"USD","amount":"1000","workspace_id":null,"model":"claude-opus-4"}
+				  ]}
+				],"has_more":true,"next_page":"page2"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":[
+			  {"starting_at":"2026-03-03T00:00:00Z","results":[
+			    {"currency":"USD","amount":"250","workspace_id":"wrkspc_1","model":"claude-sonnet-4"}
+			  ]},
+			  {"starting_at":"2026-03-04T00:00:00Z","results":[
+			    {"currency":"USD","amount":"125.5","workspace_id":"wrkspc_1","model":"claude-sonnet-4"},
+			    {"currency":"USD","amount":"50","workspace_id":"wrkspc_2","description":"Web search"}
+			  ]}
+			],"has_more":false}`))
+		case "/v1/organizations/workspaces":
+			_, _ = w.Write([]byte(`{"data":[{"id":"wrkspc_1","name":"Agents"}]}`))
+		case "/v1/organizations/usage_report/messages":
+			_, _ = w.Write([]byte(`{"data":[{"starting_at":"2026-03-04T00:00:00Z","results":[
+			  {"model":"claude-sonnet-4","uncached_input_tokens":1000,"cache_read_input_tokens":500,"output_tokens":200,"cache_creation":{"ephemeral_5m_input_tokens":300}}
+			]}],"has_more":false}`))
+		default:
+			t.Fatalf("unexpected path: %s", req.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	r := AnthropicAdmin{BaseURL: srv.URL}.fetch(context.Background(), now)
+
+	if r.Error != nil {
+		t.Fatalf("expected success, got error: %v", r.Error)
+	}
+	if r.Short != "$14.25" {
+		t.Fatalf("unexpected short value: %q", r.Short)
+	}
+
+	want := []SpendEntry{
+		{Label: "Today", Amount: 1.755},
+		{Label: "This week", Amount: 4.255},
+		{Label: "This month", Amount: 14.255},
+	}
+	if len(r.Spend) != len(want) {
+		t.Fatalf("unexpected spend rows: %#v", r.Spend)
+	}
+	for i, w := range want {
+		if r.Spend[i].Label != w.Label || !approxEqual(r.Spend[i].Amount, w.Amount) {
+			t.Fatalf("spend[%d]: got %#v, want %#v", i, r.Spend[i], w)
+		}
+	}
+
+	rows := map[string]BreakdownEntry{}
+	for _, b := range r.Breakdown {
+		rows[b.Group+"/"+b.Label] = b
+	}
+	if b, ok := rows["This month by workspace/Default"]; !ok || !approxEqual(b.Amount, 10) {
+		t.Fatalf("expected default workspace row, got %#v", r.Breakdown)
+	}
+	if b, ok := rows["Today by workspace/Agents"]; !ok || !approxEqual(b.Amount, 1.255) {
+		t.Fatalf("expected named workspace row, got %#v", r.Breakdown)
+	}
+	if _, ok := rows["Today by workspace/wrkspc_2"]; !ok {
+		t.Fatalf("expected unnamed workspace to fall back to id, got %#v", r.Breakdown)
+	}
+	if _, ok := rows["Today by model/Web search"]; !ok {
+		t.Fatalf("expected description fallback for model, got %#v", r.Breakdown)
+	}
+	if b := rows["This month by model/claude-sonnet-4"]; b.Tokens != 2000 {
+		t.Fatalf("expected usage report tokens merged into model row, got %#v", b)
+	}
+}
+
+func TestAnthropicAdminWorkspaceNamesFollowPages(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Query().Get("after_id") {
+		case "":
+			_, _ = w.Write([]byte(`{"data":[{"id":"wrkspc_1","name":"Agents"}],"has_more":true,"last_id":"wrkspc_1"}`))
+		case "wrkspc_1":
+			_, _ = w.Write([]byte(`{"data":[{"id":"wrkspc_2","name":"Batch"}],"has_more":false,"last_id":"wrkspc_2"}`))
+		default:
+			t.Fatalf("unexpected cursor %q", req.URL.Query().Get("after_id"))
+		}
+	}))
+	defer srv.Close()
+
+	names := AnthropicAdmin{BaseURL: srv.URL}.workspaceNames(context.Background(), "sk-ant-admin-test")
+	if names["wrkspc_1"] != "Agents" || names["wrkspc_2"] != "Batch" {
+		t.Fatalf("expected workspaces from both pages, got %v", names)
+	}
+}
+
+func TestAnthropicAdminFetchTruncatedReport(t *testing.T) {
+	t.Setenv("ANTHROPIC_ADMIN_KEY", "sk-ant-admin-test")
+
+	var pages int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		pages++
+		_, _ = w.Write([]byte(`{"data":[],"has_more":true,"next_page":"more"}`))
+	}))
+	defer srv.Close()
+
+	r := AnthropicAdmin{BaseURL: srv.URL}.Fetch(context.Background())
+	if r.Error == nil || r.Error.Error() != "report truncated after 20 pages" || r.Short != "?" {
+		t.Fatalf("expected a truncation error, got %q (%v)", r.Short, r.Error)
+	}
+	if pages != maxReportPages {
+		t.Fatalf("expected %d requests, got %d", maxReportPages, pages)
+	}
+}
+
+func approxEqual(a, b float64) bool {
+	d := a - b
+	return d < 1e-9 && d > -1e-9
+}
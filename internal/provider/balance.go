@@ -0,0 +1,257 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Balance reports the prepaid balance of an OpenAI-compatible vendor that
+// exposes a "user balance" endpoint. Each vendor is configured through
+// environment variables named after Prefix: <Prefix>_API_KEY,
+// <Prefix>_BASE_URL and the <Prefix>_BALANCE_WARNING / _CRITICAL floors.
+// Use the vendor constructors (DeepSeek, Moonshot, SiliconFlow) rather than
+// building one directly.
+type Balance struct {
+	Vendor  string
+	Prefix  string
+	BaseURL string
+
+	path   string
+	decode func(body []byte) (balanceInfo, error)
+}
+
+// balanceInfo is a vendor's balance response in a common shape. Currency
+// may be empty when the vendor doesn't report one. Parts split the total
+// into its sources under the "Balance" group, e.g. topped up and granted.
+type balanceInfo struct {
+	Total    float64
+	Currency string
+	Parts    []BreakdownEntry
+}
+
+const (
+	balanceGroup = "Balance"
+
+	defaultBalanceWarning  = 5.0
+	defaultBalanceCritical = 1.0
+
+	deepSeekBaseURL    = "https://api.deepseek.com"
+	moonshotBaseURL    = "https://api.moonshot.ai"
+	siliconFlowBaseURL = "https://api.siliconflow.cn"
+)
+
+// DeepSeek reports the DeepSeek platform balance (DEEPSEEK_API_KEY).
+func DeepSeek() Balance {
+	return Balance{
+		Vendor: "DeepSeek",
+		Prefix: "DEEPSEEK",
+		path:   "/user/balance",
+		decode: decodeDeepSeekBalance,
+	}
+}
+
+// Moonshot reports the Moonshot (Kimi) platform balance (MOONSHOT_API_KEY).
+// The international platform bills in USD; pointing MOONSHOT_BASE_URL at
+// api.moonshot.cn switches to the CNY-billed China platform.
+func Moonshot() Balance {
+	return Balance{
+		Vendor: "Moonshot",
+		Prefix: "MOONSHOT",
+		path:   "/v1/users/me/balance",
+		decode: decodeMoonshotBalance,
+	}
+}
+
+// SiliconFlow reports the SiliconFlow account balance (SILICONFLOW_API_KEY).
+func SiliconFlow() Balance {
+	return Balance{
+		Vendor: "SiliconFlow",
+		Prefix: "SILICONFLOW",
+		path:   "/v1/user/info",
+		decode: decodeSiliconFlowBalance,
+	}
+}
+
+func (b Balance) Name() string { return b.Vendor }
+
+func (b Balance) Configured() bool { return os.Getenv(b.Prefix+"_API_KEY") != "" }
+
+func (b Balance) Fetch(ctx context.Context) Result {
+	r := Result{Name: b.Vendor}
+
+	key := credential(os.Getenv(b.Prefix + "_API_KEY"))
+	if key == "" {
+		r.Error = fmt.Errorf("%s_API_KEY not set", b.Prefix)
+		r.Short = "?"
+		return r
+	}
+
+	var raw json.RawMessage
+	err := getJSON(ctx, b.baseURL()+b.path, func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+key)
+		req.Header.Set("Accept", "application/json")
+	}, strings.ToLower(b.Vendor)+" api key", &raw)
+	var info balanceInfo
+	if err == nil {
+		info, err = b.decode(raw)
+	}
+	if err != nil {
+		r.Error = err
+		r.Short = adminShort(err)
+		return r
+	}
+
+	r.Currency = info.Currency
+	if r.Currency == "" {
+		r.Currency = b.hostCurrency()
+	}
+	r.Credits = &info.Total
+	r.Breakdown = info.Parts
+	r.Short = fmt.Sprintf("%s%.2f", CurrencySymbol(r.Currency), info.Total)
+	r.Class = floorClass(info.Total, b.floor("WARNING", defaultBalanceWarning), b.floor("CRITICAL", defaultBalanceCritical))
+	return r
+}
+
+func (b Balance) baseURL() string {
+	base := b.BaseURL
+	if base == "" {
+		base = os.Getenv(b.Prefix + "_BASE_URL")
+	}
+	if base == "" {
+		base = endpointURL(strings.ToLower(b.Prefix))
+	}
+	return strings.TrimRight(base, "/")
+}
+
+// hostCurrency guesses the billing currency for vendors that don't report
+// one: mainland China (.cn) platforms bill in CNY, the rest in USD.
+func (b Balance) hostCurrency() string {
+	u, err := url.Parse(b.baseURL())
+	if err == nil && strings.HasSuffix(u.Hostname(), ".cn") {
+		return "CNY"
+	}
+	return "USD"
+}
+
+// floor reads <Prefix>_BALANCE_<level>, in the account's currency.
+func (b Balance) floor(level string, def float64) float64 {
+	v, err := strconv.ParseFloat(os.Getenv(b.Prefix+"_BALANCE_"+level), 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// floorClass classifies a remaining balance against warning and critical
+// floors. An empty balance is always critical.
+func floorClass(balance, warning, critical float64) string {
+	switch {
+	case balance <= 0 || balance < critical:
+		return "critical"
+	case balance < warning:
+		return "warning"
+	default:
+		return "normal"
+	}
+}
+
+// decodeDeepSeekBalance picks the first currency with a non-zero balance,
+// since accounts usually hold only one of CNY or USD.
+func decodeDeepSeekBalance(body []byte) (balanceInfo, error) {
+	var resp struct {
+		IsAvailable  bool `json:"is_available"`
+		BalanceInfos []struct {
+			Currency        string `json:"currency"`
+			TotalBalance    string `json:"total_balance"`
+			GrantedBalance  string `json:"granted_balance"`
+			ToppedUpBalance string `json:"topped_up_balance"`
+		} `json:"balance_infos"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return balanceInfo{}, err
+	}
+	if len(resp.BalanceInfos) == 0 {
+		return balanceInfo{}, fmt.Errorf("deepseek returned no balance")
+	}
+
+	pick := resp.BalanceInfos[0]
+	for _, bi := range resp.BalanceInfos {
+		if parseAmount(bi.TotalBalance) > 0 {
+			pick = bi
+			break
+		}
+	}
+
+	return balanceInfo{
+		Total:    parseAmount(pick.TotalBalance),
+		Currency: pick.Currency,
+		Parts: []BreakdownEntry{
+			{Group: balanceGroup, Label: "Topped up", Amount: parseAmount(pick.ToppedUpBalance)},
+			{Group: balanceGroup, Label: "Granted", Amount: parseAmount(pick.GrantedBalance)},
+		},
+	}, nil
+}
+
+func decodeMoonshotBalance(body []byte) (balanceInfo, error) {
+	var resp struct {
+		Data *struct {
+			AvailableBalance float64 `json:"available_balance"`
+			VoucherBalance   float64 `json:"voucher_balance"`
+			CashBalance      float64 `json:"cash_balance"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return balanceInfo{}, err
+	}
+	if resp.Data == nil {
+		return balanceInfo{}, fmt.Errorf("moonshot returned no balance")
+	}
+
+	return balanceInfo{
+		Total: resp.Data.AvailableBalance,
+		Parts: []BreakdownEntry{
+			{Group: balanceGroup, Label: "Cash", Amount: resp.Data.CashBalance},
+			{Group: balanceGroup, Label: "Vouchers", Amount: resp.Data.VoucherBalance},
+		},
+	}, nil
+}
+
+func decodeSiliconFlowBalance(body []byte) (balanceInfo, error) {
+	var resp struct {
+		Data *struct {
+			Balance       string `json:"balance"`
+			ChargeBalance string `json:"chargeBalance"`
+			TotalBalance  string `json:"totalBalance"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return balanceInfo{}, err
+	}
+	if resp.Data == nil {
+		return balanceInfo{}, fmt.Errorf("siliconflow returned no balance")
+	}
+
+	return balanceInfo{
+		Total:    parseAmount(resp.Data.TotalBalance),
+		Currency: "CNY",
+		Parts: []BreakdownEntry{
+			{Group: balanceGroup, Label: "Topped up", Amount: parseAmount(resp.Data.ChargeBalance)},
+			{Group: balanceGroup, Label: "Granted", Amount: parseAmount(resp.Data.Balance)},
+		},
+	}, nil
+}
+
+// parseAmount parses a decimal string amount, treating garbage as zero.
+func parseAmount(s string) float64 {
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
@@ -0,0 +1,125 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBalanceFetchRequiresKey(t *testing.T) {
+	t.Setenv("DEEPSEEK_API_KEY", "")
+
+	r := DeepSeek().Fetch(context.Background())
+	if r.Error == nil {
+		t.Fatal("expected missing key error")
+	}
+	if r.Short != "?" {
+		t.Fatalf("expected short '?', got %q", r.Short)
+	}
+}
+
+func TestBalanceFetchAuthFailure(t *testing.T) {
+	t.Setenv("MOONSHOT_API_KEY", "sk-test")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	b := Moonshot()
+	b.BaseURL = srv.URL
+	r := b.Fetch(context.Background())
+	if r.Error == nil {
+		t.Fatal("expected auth error")
+	}
+	if r.Short != "!" {
+		t.Fatalf("expected short '!', got %q", r.Short)
+	}
+}
+
+func TestDeepSeekBalancePicksFundedCurrency(t *testing.T) {
+	t.Setenv("DEEPSEEK_API_KEY", "sk-test")
+	t.Setenv("DEEPSEEK_BALANCE_WARNING", "50")
+	t.Setenv("DEEPSEEK_BALANCE_CRITICAL", "")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/user/balance" {
+			t.Fatalf("unexpected path %q", req.URL.Path)
+		}
+		if req.Header.Get("Authorization") != "Bearer sk-test" {
+			t.Fatalf("missing auth header: %q", req.Header.Get("Authorization"))
+		}
+		writeJSON(w, `{"is_available":true,"balance_infos":[
+		  {"currency":"USD","total_balance":"0.00","granted_balance":"0.00","topped_up_balance":"0.00"},
+		  {"currency":"CNY","total_balance":"42.50","granted_balance":"2.50","topped_up_balance":"40.00"}
+		]}`)
+	}))
+	defer srv.Close()
+
+	b := DeepSeek()
+	b.BaseURL = srv.URL
+	r := b.Fetch(context.Background())
+	if r.Error != nil {
+		t.Fatalf("unexpected error: %v", r.Error)
+	}
+	if r.Currency != "CNY" || r.Credits == nil || *r.Credits != 42.5 {
+		t.Fatalf("expected ¥42.50 credits, got %v %v", r.Currency, r.Credits)
+	}
+	if r.Short != "¥42.50" {
+		t.Fatalf("expected short '¥42.50', got %q", r.Short)
+	}
+	if r.Class != "warning" {
+		t.Fatalf("expected warning below floor, got %q", r.Class)
+	}
+	if len(r.Spend) != 0 {
+		t.Fatalf("balance parts reported as spend: %+v", r.Spend)
+	}
+	if len(r.Breakdown) != 2 || r.Breakdown[0].Group != "Balance" || r.Breakdown[0].Amount != 40 || r.Breakdown[1].Amount != 2.5 {
+		t.Fatalf("unexpected balance parts: %+v", r.Breakdown)
+	}
+}
+
+func TestMoonshotBalanceCurrencyFollowsHost(t *testing.T) {
+	t.Setenv("MOONSHOT_API_KEY", "sk-test")
+
+	b := Moonshot()
+	if got := b.hostCurrency(); got != "USD" {
+		t.Fatalf("expected USD for the international platform, got %q", got)
+	}
+	b.BaseURL = "https://api.moonshot.cn"
+	if got := b.hostCurrency(); got != "CNY" {
+		t.Fatalf("expected CNY for the China platform, got %q", got)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, `{"code":0,"data":{"available_balance":0.5,"voucher_balance":0.5,"cash_balance":0},"status":true}`)
+	}))
+	defer srv.Close()
+
+	b.BaseURL = srv.URL
+	r := b.Fetch(context.Background())
+	if r.Error != nil {
+		t.Fatalf("unexpected error: %v", r.Error)
+	}
+	if r.Short != "$0.50" || r.Class != "critical" {
+		t.Fatalf("expected critical $0.50, got %q %q", r.Short, r.Class)
+	}
+}
+
+func TestFloorClass(t *testing.T) {
+	tests := []struct {
+		balance float64
+		want    string
+	}{
+		{balance: 0, want: "critical"},
+		{balance: 0.5, want: "critical"},
+		{balance: 3, want: "warning"},
+		{balance: 5, want: "normal"},
+	}
+	for _, tt := range tests {
+		if got := floorClass(tt.balance, 5, 1); got != tt.want {
+			t.Fatalf("floorClass(%v) = %q, want %q", tt.balance, got, tt.want)
+		}
+	}
+}
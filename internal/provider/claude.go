@@ -57,15 +57,26 @@ type claudeProfileResponse struct {
 }
 
 const (
-	claudeUsageURL        = "https://api.anthropic.com/api/oauth/usage"
-	claudeProfileURL      = "https://api.anthropic.com/api/oauth/profile"
+	claudeOAuthBaseURL    = "https://api.anthropic.com/api/oauth"
 	claudeTokenURL        = "https://platform.claude.com/v1/oauth/token"
 	claudeOAuthClientID   = "9d1c250a-e61b-44d9-88ed-5944d1962f5e"
 	claudeUserAgent       = "claude-code/2.1.32"
 	claudeAuthFailedError = "claude auth expired; run `claude login`"
 )
 
+// Fetch combines the usage API with token counts from local transcripts
+// and their API-equivalent cost estimates; both are attached even when the
+// API call fails.
 func (c Claude) Fetch(ctx context.Context) Result {
+	r := c.fetchUsage(ctx)
+	r.Tokens = claudeTokenUsage(ctx, r.Windows, time.Now())
+	if len(r.Tokens) > 0 {
+		r.Spend = append(r.Spend, estimateSpend(r.Tokens, loadPricing())...)
+	}
+	return r
+}
+
+func (c Claude) fetchUsage(ctx context.Context) Result {
 	r := Result{Name: "Claude"}
 
 	creds, err := loadClaudeCredentials()
@@ -165,14 +176,14 @@ func (c Claude) Fetch(ctx context.Context) Result {
 func fetchClaudeUsage(ctx context.Context, accessToken string) (claudeUsageResponse, int, error) {
 	var usage claudeUsageResponse
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, claudeUsageURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointURL("claude")+"/usage", nil)
 	if err != nil {
 		return usage, 0, err
 	}
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("anthropic-beta", "oauth-2025-04-20")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return usage, 0, err
 	}
@@ -189,7 +200,9 @@ func fetchClaudeUsage(ctx context.Context, accessToken string) (claudeUsageRespo
 	return usage, http.StatusOK, nil
 }
 
-func refreshClaudeAuth(ctx context.Context, creds *claudeCredentials) error {
+func refreshClaudeAuth(ctx context.Context, creds *claudeCredentials) (err error) {
+	defer func() { logRefresh(ctx, err) }()
+
 	if creds.ClaudeAiOauth.RefreshToken == "" {
 		return fmt.Errorf("no Claude refresh token found")
 	}
@@ -199,7 +212,7 @@ func refreshClaudeAuth(ctx context.Context, creds *claudeCredentials) error {
 	form.Set("refresh_token", creds.ClaudeAiOauth.RefreshToken)
 	form.Set("client_id", claudeOAuthClientID)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, claudeTokenURL, strings.NewReader(form.Encode()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpointURL("claude_token"), strings.NewReader(form.Encode()))
 	if err != nil {
 		return err
 	}
@@ -207,7 +220,7 @@ func refreshClaudeAuth(ctx context.Context, creds *claudeCredentials) error {
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("User-Agent", claudeUserAgent)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -250,14 +263,14 @@ func isClaudeAuthStatus(status int) bool {
 }
 
 func fetchClaudeProfile(ctx context.Context, token string) string {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, claudeProfileURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointURL("claude")+"/profile", nil)
 	if err != nil {
 		return ""
 	}
 	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("anthropic-beta", "oauth-2025-04-20")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil || resp.StatusCode != 200 {
 		return ""
 	}
@@ -271,6 +284,13 @@ func fetchClaudeProfile(ctx context.Context, token string) string {
 }
 
 func loadClaudeCredentials() (*claudeCredentials, error) {
+	if replaying() {
+		var creds claudeCredentials
+		creds.ClaudeAiOauth.AccessToken = replayCredential
+		creds.ClaudeAiOauth.RefreshToken = replayCredential
+		return &creds, nil
+	}
+
 	path, err := claudeCredentialsPath()
 	if err != nil {
 		return nil, err
@@ -294,6 +314,10 @@ func loadClaudeCredentials() (*claudeCredentials, error) {
 }
 
 func saveClaudeCredentials(creds *claudeCredentials) error {
+	if replaying() {
+		return nil // keep the real tokens, not the tape's placeholders
+	}
+
 	path, err := claudeCredentialsPath()
 	if err != nil {
 		return err
@@ -115,11 +115,12 @@ func TestSaveClaudeCredentialsPreservesUnknownFields(t *testing.T) {
 }
 
 func TestFetchClaudeUsageSuccess(t *testing.T) {
+	resetEndpoints(t)
 	withMockDefaultClient(t, func(req *http.Request) (*http.Response, error) {
 		if req.Method != http.MethodGet {
 			t.Fatalf("expected GET, got %s", req.Method)
 		}
-		if req.URL.String() != claudeUsageURL {
+		if req.URL.String() != "https://api.anthropic.com/api/oauth/usage" {
 			t.Fatalf("unexpected URL: %s", req.URL.String())
 		}
 		if req.Header.Get("Authorization") != "Bearer token123" {
@@ -218,3 +219,25 @@ func TestRefreshClaudeAuthRequiresRefreshToken(t *testing.T) {
 		t.Fatalf("expected missing refresh token error, got %v", err)
 	}
 }
+
+func TestClaudeFetchReplaysRefreshAfter401(t *testing.T) {
+	resetEndpoints(t)
+	creds := `{"claudeAiOauth":{"accessToken":"stale","refreshToken":"refresh-1","subscriptionType":"max"}}`
+	path := writeCredentials(t, filepath.Join(".claude", ".credentials.json"), creds)
+	replayTape(t, "claude-refresh-after-401")
+
+	r := Claude{}.fetchUsage(context.Background())
+	if r.Error != nil {
+		t.Fatalf("unexpected error: %v", r.Error)
+	}
+	if r.Short != "63%" || r.Class != classFromPct(63) || len(r.Windows) != 2 || r.Identity != "user@example.com" {
+		t.Fatalf("unexpected result: %+v", r)
+	}
+
+	// The tape's refreshed tokens are placeholders and must not replace
+	// the real ones.
+	data, _ := os.ReadFile(path)
+	if string(data) != creds {
+		t.Fatalf("credentials were rewritten during replay: %s", data)
+	}
+}
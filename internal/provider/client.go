@@ -0,0 +1,180 @@
+package provider
+
+import (
+	"bytes"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jhartzell/ai-usage-bar/internal/diag"
+)
+
+// retryClient sends requests through the provider's client (see
+// clientFor; http.DefaultClient unless network settings apply), retrying
+// transient failures of idempotent requests with jittered exponential
+// backoff. Every wait stays within the request context's deadline, which
+// FetchAll sets per provider, so retries never delay the bar.
+type retryClient struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+// httpClient is what providers send their requests through.
+var httpClient = retryClient{
+	maxAttempts: 3,
+	baseDelay:   250 * time.Millisecond,
+	maxDelay:    2 * time.Second,
+}
+
+// RateLimitedError reports that an endpoint answered 429 Too Many Requests
+// and retrying within the deadline didn't help.
+type RateLimitedError struct {
+	RetryAfter time.Duration // zero when the server didn't say
+}
+
+func (e *RateLimitedError) Error() string {
+	if e.RetryAfter > 0 {
+		return "rate limited, retry in " + e.RetryAfter.Round(time.Second).String()
+	}
+	return "rate limited"
+}
+
+// Do sends req. Network errors, 429 and 502/503/504 are retried for GET and
+// HEAD; other methods are sent once since they may not be safe to repeat.
+// A final 429 is returned as a *RateLimitedError with the body closed; any
+// other final response is returned as is.
+func (c retryClient) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	retryable := req.Method == http.MethodGet || req.Method == http.MethodHead
+
+	for attempt := 1; ; attempt++ {
+		resp, err := send(req)
+		if err != nil && ctx.Err() != nil {
+			return nil, err
+		}
+
+		var wait time.Duration
+		var limited *RateLimitedError
+		switch {
+		case err != nil:
+			wait = c.backoff(attempt)
+		case resp.StatusCode == http.StatusTooManyRequests:
+			limited = &RateLimitedError{RetryAfter: retryAfter(resp.Header, time.Now())}
+			wait = max(limited.RetryAfter, c.backoff(attempt))
+		case isTransientStatus(resp.StatusCode):
+			wait = max(retryAfter(resp.Header, time.Now()), c.backoff(attempt))
+		default:
+			return resp, nil
+		}
+
+		if !retryable || attempt >= c.maxAttempts || !fitsDeadline(req, wait) {
+			if limited != nil {
+				drainClose(resp)
+				return nil, limited
+			}
+			return resp, err
+		}
+		if resp != nil {
+			drainClose(resp)
+		}
+		diag.Logf("%s: retrying %s %s in %v (attempt %d of %d)", providerName(ctx), req.Method, req.URL, wait.Round(time.Millisecond), attempt+1, c.maxAttempts)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if limited != nil {
+				return nil, limited
+			}
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// maxLoggedBody is how much of a response body the diagnostic log keeps.
+const maxLoggedBody = 512
+
+// send makes a single attempt through the provider's client, logging the
+// request, status, latency and start of the body when diagnostics are on.
+func send(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if !diag.Enabled() {
+		return clientFor(ctx).Do(req)
+	}
+
+	start := time.Now()
+	resp, err := clientFor(ctx).Do(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		diag.Logf("%s: %s %s failed after %v: %v", providerName(ctx), req.Method, req.URL, elapsed, err)
+		return nil, err
+	}
+
+	// Peek at the body and put it back for the caller.
+	peek, _ := io.ReadAll(io.LimitReader(resp.Body, maxLoggedBody+1))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(peek), resp.Body), resp.Body}
+
+	diag.Logf("%s: %s %s -> %d in %v: %s", providerName(ctx), req.Method, req.URL, resp.StatusCode, elapsed, diag.Truncate(string(peek), maxLoggedBody))
+	return resp, nil
+}
+
+// backoff returns the wait before the given retry: baseDelay doubling per
+// attempt, capped at maxDelay, with the upper half jittered so concurrent
+// clients spread out.
+func (c retryClient) backoff(attempt int) time.Duration {
+	d := c.baseDelay << (attempt - 1)
+	if d <= 0 || d > c.maxDelay {
+		d = c.maxDelay
+	}
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + rand.N(half)
+}
+
+func isTransientStatus(status int) bool {
+	switch status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// fitsDeadline reports whether waiting d still leaves the request's context
+// time for another attempt.
+func fitsDeadline(req *http.Request, d time.Duration) bool {
+	deadline, ok := req.Context().Deadline()
+	return !ok || time.Until(deadline) > d
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP
+// date. It returns zero when the header is missing or malformed.
+func retryAfter(h http.Header, now time.Time) time.Duration {
+	v := strings.TrimSpace(h.Get("Retry-After"))
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// drainClose discards a little of the body so the connection can be
+// reused, then closes it.
+func drainClose(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+}
@@ -0,0 +1,215 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jhartzell/ai-usage-bar/internal/diag"
+)
+
+func withFastRetries(t *testing.T) {
+	t.Helper()
+	old := httpClient
+	httpClient = retryClient{maxAttempts: 3, baseDelay: time.Millisecond, maxDelay: 5 * time.Millisecond}
+	t.Cleanup(func() { httpClient = old })
+}
+
+func TestRetryClientRetriesTransientFailures(t *testing.T) {
+	withFastRetries(t)
+
+	attempts := 0
+	withMockDefaultClient(t, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		switch attempts {
+		case 1:
+			return nil, errors.New("connection reset")
+		case 2:
+			return jsonResponse(http.StatusBadGateway, "bad gateway"), nil
+		default:
+			return jsonResponse(http.StatusOK, `{}`), nil
+		}
+	})
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "https://example.test/usage", nil)
+	resp, err := httpClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected success after retries, got %v, %v", resp, err)
+	}
+	resp.Body.Close()
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestRetryClientReturnsLastResponseWhenAttemptsRunOut(t *testing.T) {
+	withFastRetries(t)
+
+	attempts := 0
+	withMockDefaultClient(t, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return jsonResponse(http.StatusServiceUnavailable, ""), nil
+	})
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "https://example.test/usage", nil)
+	resp, err := httpClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected final 503 response, got %v, %v", resp, err)
+	}
+	resp.Body.Close()
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestRetryClientDoesNotRetryPost(t *testing.T) {
+	withFastRetries(t)
+
+	attempts := 0
+	withMockDefaultClient(t, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return jsonResponse(http.StatusBadGateway, ""), nil
+	})
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, "https://example.test/token", strings.NewReader("x"))
+	resp, err := httpClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected 502 response, got %v, %v", resp, err)
+	}
+	resp.Body.Close()
+	if attempts != 1 {
+		t.Fatalf("expected a single attempt, got %d", attempts)
+	}
+}
+
+func TestRetryClientRateLimited(t *testing.T) {
+	withFastRetries(t)
+
+	attempts := 0
+	withMockDefaultClient(t, func(req *http.Request) (*http.Response, error) {
+		attempts++
+		resp := jsonResponse(http.StatusTooManyRequests, "")
+		resp.Header.Set("Retry-After", "30")
+		return resp, nil
+	})
+
+	// Waiting 30s would overrun the deadline, so it gives up at once.
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://example.test/usage", nil)
+
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if resp != nil {
+		t.Fatalf("expected no response, got %v", resp)
+	}
+	var limited *RateLimitedError
+	if !errors.As(err, &limited) || limited.RetryAfter != 30*time.Second {
+		t.Fatalf("expected rate-limited error, got %v", err)
+	}
+	if err.Error() != "rate limited, retry in 30s" {
+		t.Fatalf("unexpected message: %q", err.Error())
+	}
+	if attempts != 1 || time.Since(start) > time.Second {
+		t.Fatalf("expected an immediate give-up, got %d attempts in %v", attempts, time.Since(start))
+	}
+}
+
+func TestRetryClientHonoursShortRetryAfter(t *testing.T) {
+	withFastRetries(t)
+
+	var times []time.Time
+	withMockDefaultClient(t, func(req *http.Request) (*http.Response, error) {
+		times = append(times, time.Now())
+		if len(times) == 1 {
+			resp := jsonResponse(http.StatusTooManyRequests, "")
+			resp.Header.Set("Retry-After", "1")
+			return resp, nil
+		}
+		return jsonResponse(http.StatusOK, `{}`), nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://example.test/usage", nil)
+
+	resp, err := httpClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected success after waiting, got %v, %v", resp, err)
+	}
+	resp.Body.Close()
+	if gap := times[1].Sub(times[0]); gap < time.Second {
+		t.Fatalf("expected to wait for Retry-After, waited %v", gap)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{in: "120", want: 2 * time.Minute},
+		{in: now.Add(90 * time.Second).Format(http.TimeFormat), want: 90 * time.Second},
+		{in: now.Add(-time.Minute).Format(http.TimeFormat), want: 0},
+		{in: "soon", want: 0},
+		{in: "", want: 0},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		if tt.in != "" {
+			h.Set("Retry-After", tt.in)
+		}
+		if got := retryAfter(h, now); got != tt.want {
+			t.Fatalf("retryAfter(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRetryClientBackoffIsBounded(t *testing.T) {
+	c := retryClient{maxAttempts: 10, baseDelay: 100 * time.Millisecond, maxDelay: time.Second}
+	for attempt := 1; attempt <= 10; attempt++ {
+		d := c.backoff(attempt)
+		if d < 50*time.Millisecond || d > time.Second {
+			t.Fatalf("attempt %d: backoff %v out of bounds", attempt, d)
+		}
+	}
+}
+
+func TestSendLogsRequests(t *testing.T) {
+	var log bytes.Buffer
+	diag.Enable(&log)
+	t.Cleanup(diag.Close)
+
+	body := `{"error":"invalid_grant","refresh_token":"rt-secret"}`
+	withMockDefaultClient(t, func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusBadRequest, body), nil
+	})
+
+	ctx := withProviderName(context.Background(), "Claude")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "https://example.test/token", strings.NewReader("x"))
+	req.Header.Set("Authorization", "Bearer at-secret")
+	resp, err := send(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(got) != body {
+		t.Fatalf("logging consumed the body: %q", got)
+	}
+
+	out := log.String()
+	if !strings.Contains(out, `Claude: POST https://example.test/token -> 400 in `) || !strings.Contains(out, `"error":"invalid_grant"`) {
+		t.Fatalf("unexpected log: %q", out)
+	}
+	if strings.Contains(out, "rt-secret") || strings.Contains(out, "at-secret") {
+		t.Fatalf("secret leaked into log: %q", out)
+	}
+}
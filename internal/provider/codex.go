@@ -10,6 +10,8 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/jhartzell/ai-usage-bar/internal/transcripts"
 )
 
 type Codex struct{}
@@ -51,13 +53,88 @@ type codexWindow struct {
 }
 
 const (
-	codexUsageURL        = "https://chatgpt.com/backend-api/wham/usage"
+	codexBackendBaseURL  = "https://chatgpt.com/backend-api"
 	codexTokenURL        = "https://auth.openai.com/oauth/token"
 	codexOAuthClientID   = "app_EMoamEEZ73f0CkXaXp7hrann"
 	codexAuthFailedError = "codex auth expired; run `codex login`"
 )
 
+// Fetch combines the usage API with token counts from local session logs
+// and their API-equivalent cost estimates; both are attached even when the
+// API call fails. If the API can't be reached, the rate limits the CLI last
+// logged are shown instead. An auth failure stays an error, since only
+// `codex login` fixes it, but carries the logged limits along.
 func (c Codex) Fetch(ctx context.Context) Result {
+	now := time.Now()
+	r := c.fetchUsage(ctx)
+	if r.Error != nil {
+		if fallback, ok := codexLogFallback(r, now); ok {
+			if r.Short == "!" {
+				r.Windows, r.Source, r.AsOf = fallback.Windows, fallback.Source, fallback.AsOf
+			} else {
+				r = fallback
+			}
+		}
+	}
+	r.Tokens = codexTokenUsage(ctx, now)
+	if len(r.Tokens) > 0 {
+		r.Spend = append(r.Spend, estimateSpend(r.Tokens, loadPricing())...)
+	}
+	return r
+}
+
+// codexLogFallback builds a result from the freshest rate-limit snapshot
+// in the CLI's session logs. Windows whose reset has passed since the
+// snapshot are shown as empty.
+func codexLogFallback(live Result, now time.Time) (Result, bool) {
+	dir, err := transcripts.CodexDir()
+	if err != nil {
+		return live, false
+	}
+	snap, ok := transcripts.LatestCodexRateLimits(dir)
+	if !ok {
+		return live, false
+	}
+
+	r := Result{
+		Name:     live.Name,
+		Identity: live.Identity,
+		Plan:     live.Plan,
+		Class:    "normal",
+		Source:   "session log",
+		AsOf:     snap.Time,
+	}
+
+	for _, w := range []struct {
+		label string
+		win   *transcripts.RateLimitWindow
+	}{
+		{label: "Session (5h)", win: snap.Primary},
+		{label: "Weekly (7d)", win: snap.Secondary},
+	} {
+		if w.win == nil {
+			continue
+		}
+		rw := RateWindow{Label: w.label, UsedPct: w.win.UsedPercent}
+		if !w.win.ResetsAt.IsZero() {
+			if w.win.ResetsAt.After(now) {
+				rw.ResetAt, rw.HasReset = w.win.ResetsAt, true
+			} else {
+				rw.UsedPct = 0
+			}
+		}
+		r.Windows = append(r.Windows, rw)
+	}
+
+	if len(r.Windows) == 0 {
+		return live, false
+	}
+	r.Short = fmt.Sprintf("%.0f%%", r.Windows[0].UsedPct)
+	r.Class = classFromPct(r.Windows[0].UsedPct)
+	return r, true
+}
+
+func (c Codex) fetchUsage(ctx context.Context) Result {
 	r := Result{Name: "Codex"}
 
 	auth, err := loadCodexAuth()
@@ -152,13 +229,13 @@ func (c Codex) Fetch(ctx context.Context) Result {
 func fetchCodexUsage(ctx context.Context, accessToken string) (codexUsageResponse, int, error) {
 	var usage codexUsageResponse
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, codexUsageURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointURL("codex")+"/wham/usage", nil)
 	if err != nil {
 		return usage, 0, err
 	}
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return usage, 0, err
 	}
@@ -175,7 +252,9 @@ func fetchCodexUsage(ctx context.Context, accessToken string) (codexUsageRespons
 	return usage, http.StatusOK, nil
 }
 
-func refreshCodexAuth(ctx context.Context, auth *codexAuth) error {
+func refreshCodexAuth(ctx context.Context, auth *codexAuth) (err error) {
+	defer func() { logRefresh(ctx, err) }()
+
 	if auth.Tokens.RefreshToken == "" {
 		return fmt.Errorf("no Codex refresh token found")
 	}
@@ -185,13 +264,13 @@ func refreshCodexAuth(ctx context.Context, auth *codexAuth) error {
 	form.Set("refresh_token", auth.Tokens.RefreshToken)
 	form.Set("client_id", codexOAuthClientID)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, codexTokenURL, strings.NewReader(form.Encode()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpointURL("codex_token"), strings.NewReader(form.Encode()))
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -226,6 +305,13 @@ func isCodexAuthStatus(status int) bool {
 }
 
 func loadCodexAuth() (*codexAuth, error) {
+	if replaying() {
+		var auth codexAuth
+		auth.Tokens.AccessToken = replayCredential
+		auth.Tokens.RefreshToken = replayCredential
+		return &auth, nil
+	}
+
 	path, err := codexAuthPath()
 	if err != nil {
 		return nil, err
@@ -249,6 +335,10 @@ func loadCodexAuth() (*codexAuth, error) {
 }
 
 func saveCodexAuth(auth *codexAuth) error {
+	if replaying() {
+		return nil // keep the real tokens, not the tape's placeholders
+	}
+
 	path, err := codexAuthPath()
 	if err != nil {
 		return err
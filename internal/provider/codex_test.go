@@ -8,8 +8,10 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestIsCodexAuthStatus(t *testing.T) {
@@ -93,11 +95,12 @@ func TestSaveCodexAuthPreservesUnknownFields(t *testing.T) {
 }
 
 func TestFetchCodexUsageSuccess(t *testing.T) {
+	resetEndpoints(t)
 	withMockDefaultClient(t, func(req *http.Request) (*http.Response, error) {
 		if req.Method != http.MethodGet {
 			t.Fatalf("expected GET, got %s", req.Method)
 		}
-		if req.URL.String() != codexUsageURL {
+		if req.URL.String() != "https://chatgpt.com/backend-api/wham/usage" {
 			t.Fatalf("unexpected URL: %s", req.URL.String())
 		}
 		if req.Header.Get("Authorization") != "Bearer token123" {
@@ -188,3 +191,120 @@ func TestRefreshCodexAuthRequiresRefreshToken(t *testing.T) {
 		t.Fatalf("expected missing refresh token error, got %v", err)
 	}
 }
+
+// writeCodexRateLimitLog writes a session log whose last rate-limit
+// snapshot has the 5h window at 80% and the weekly one already reset.
+func writeCodexRateLimitLog(t *testing.T, home string) {
+	t.Helper()
+	dir := filepath.Join(home, ".codex", "sessions", "2026", "03", "04")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	log := `{"timestamp":"` + now.Add(-10*time.Minute).UTC().Format(time.RFC3339) + `","type":"event_msg","payload":{"type":"token_count","info":null,"rate_limits":{` +
+		`"primary":{"used_percent":80,"window_minutes":300,"resets_at":` + strconv.FormatInt(now.Add(time.Hour).Unix(), 10) + `},` +
+		`"secondary":{"used_percent":55,"window_minutes":10080,"resets_at":` + strconv.FormatInt(now.Add(-time.Minute).Unix(), 10) + `}}}}` + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "rollout-1.jsonl"), []byte(log), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCodexFetchFallsBackToSessionLog(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	writeCodexRateLimitLog(t, home)
+
+	// No auth.json: the live fetch fails before any request is made.
+	r := Codex{}.Fetch(context.Background())
+	if r.Error != nil {
+		t.Fatalf("expected fallback instead of error, got %v", r.Error)
+	}
+	if r.Source != "session log" || r.AsOf.IsZero() {
+		t.Fatalf("expected fallback to be marked, got source %q as of %v", r.Source, r.AsOf)
+	}
+	if r.Short != "80%" || r.Class != "warning" {
+		t.Fatalf("unexpected short/class: %q %q", r.Short, r.Class)
+	}
+	if len(r.Windows) != 2 || !r.Windows[0].HasReset {
+		t.Fatalf("unexpected windows: %+v", r.Windows)
+	}
+	if r.Windows[1].UsedPct != 0 || r.Windows[1].HasReset {
+		t.Fatalf("expected weekly window past its reset to read 0%%, got %+v", r.Windows[1])
+	}
+}
+
+func TestCodexFetchAuthFailureKeepsErrorWithSessionLog(t *testing.T) {
+	resetEndpoints(t)
+	home := filepath.Dir(filepath.Dir(writeCredentials(t, filepath.Join(".codex", "auth.json"), `{"tokens":{"access_token":"stale","refresh_token":"revoked"}}`)))
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	writeCodexRateLimitLog(t, home)
+
+	withMockDefaultClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.Method == http.MethodPost {
+			return jsonResponse(http.StatusBadRequest, `{"error":"invalid_grant"}`), nil
+		}
+		return jsonResponse(http.StatusUnauthorized, `{"detail":"expired"}`), nil
+	})
+
+	r := Codex{}.Fetch(context.Background())
+	if r.Error == nil || r.Short != "!" || !strings.Contains(r.Error.Error(), codexAuthFailedError) {
+		t.Fatalf("expected the auth failure to be kept, got %q (%v)", r.Short, r.Error)
+	}
+	if r.Source != "session log" || len(r.Windows) != 2 || r.Windows[0].UsedPct != 80 {
+		t.Fatalf("expected the logged limits alongside the error, got source %q windows %+v", r.Source, r.Windows)
+	}
+}
+
+func TestCodexFetchKeepsErrorWhenSnapshotHasNoWindows(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := filepath.Join(home, ".codex", "sessions", "2026", "03", "04")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	log := `{"timestamp":"` + time.Now().UTC().Format(time.RFC3339) + `","type":"event_msg","payload":{"type":"token_count","info":null,"rate_limits":{}}}` + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "rollout-1.jsonl"), []byte(log), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// A snapshot with no windows must leave the live error in place rather
+	// than become an empty fallback.
+	r := Codex{}.Fetch(context.Background())
+	if r.Error == nil || r.Short != "?" || r.Source != "" {
+		t.Fatalf("expected the live error without a fallback, got %q (%v) source %q", r.Short, r.Error, r.Source)
+	}
+}
+
+func TestCodexFetchWithoutSessionLogKeepsError(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	// A snapshot with no windows must leave the live error in place rather
+	// than become an empty fallback.
+	r := Codex{}.Fetch(context.Background())
+	if r.Error == nil || r.Short != "?" || r.Source != "" {
+		t.Fatalf("expected plain error result, got %+v", r)
+	}
+}
+
+func TestCodexFetchReplaysRefreshAfter401(t *testing.T) {
+	resetEndpoints(t)
+	auth := `{"tokens":{"access_token":"stale","refresh_token":"refresh-1"}}`
+	path := writeCredentials(t, filepath.Join(".codex", "auth.json"), auth)
+	replayTape(t, "codex-refresh-after-401")
+
+	r := Codex{}.fetchUsage(context.Background())
+	if r.Error != nil {
+		t.Fatalf("unexpected error: %v", r.Error)
+	}
+	if r.Short != "100%" || r.Class != "critical" || len(r.Windows) != 2 {
+		t.Fatalf("unexpected result: %+v", r)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != auth {
+		t.Fatalf("auth was rewritten during replay: %s", data)
+	}
+}
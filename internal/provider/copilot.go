@@ -0,0 +1,256 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Copilot struct{}
+
+func (Copilot) Name() string { return "Copilot" }
+
+// Configured reports whether a Copilot editor login exists. A gh CLI login
+// alone doesn't count, since most gh users have no Copilot subscription;
+// they opt in with AI_USAGE_BAR_COPILOT=1.
+func (Copilot) Configured() bool {
+	if os.Getenv("AI_USAGE_BAR_COPILOT") == "1" {
+		return true
+	}
+	dir, err := userConfigDir()
+	if err != nil {
+		return false
+	}
+	for _, path := range []string{
+		filepath.Join(dir, "github-copilot", "apps.json"),
+		filepath.Join(dir, "github-copilot", "hosts.json"),
+	} {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+type copilotUserResponse struct {
+	Login             string                          `json:"login"`
+	CopilotPlan       string                          `json:"copilot_plan"`
+	QuotaResetDate    string                          `json:"quota_reset_date"`
+	QuotaResetDateUTC string                          `json:"quota_reset_date_utc"`
+	QuotaSnapshots    map[string]copilotQuotaSnapshot `json:"quota_snapshots"`
+}
+
+type copilotQuotaSnapshot struct {
+	Entitlement      float64 `json:"entitlement"`
+	Remaining        float64 `json:"remaining"`
+	PercentRemaining float64 `json:"percent_remaining"`
+	Unlimited        bool    `json:"unlimited"`
+}
+
+const (
+	copilotBaseURL         = "https://api.github.com"
+	copilotEditorVersion   = "vscode/1.99.0"
+	copilotAPIVersion      = "2025-04-01"
+	copilotAuthFailedError = "copilot auth failed; run `gh auth login` or sign in to Copilot in your editor"
+)
+
+// copilotQuotaLabels maps quota snapshot keys to window labels. Premium
+// requests come first because they drive Short and Class.
+var copilotQuotaLabels = []struct {
+	key   string
+	label string
+}{
+	{key: "premium_interactions", label: "Premium (monthly)"},
+	{key: "chat", label: "Chat (monthly)"},
+	{key: "completions", label: "Completions (monthly)"},
+}
+
+// ghAuthToken returns the token from `gh auth token`. It is a variable so
+// tests can stub out the gh CLI.
+var ghAuthToken = func(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "gh", "auth", "token").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (c Copilot) Fetch(ctx context.Context) Result {
+	r := Result{Name: "Copilot"}
+
+	token, err := loadCopilotToken(ctx)
+	if err != nil {
+		r.Error = err
+		r.Short = "?"
+		return r
+	}
+
+	user, status, err := fetchCopilotUser(ctx, token)
+	if err != nil {
+		r.Error = err
+		r.Short = "?"
+		return r
+	}
+
+	if status == http.StatusUnauthorized || status == http.StatusForbidden {
+		r.Error = fmt.Errorf("%s (HTTP %d)", copilotAuthFailedError, status)
+		r.Short = "!"
+		return r
+	}
+
+	if status == http.StatusNotFound {
+		r.Error = fmt.Errorf("copilot is not enabled for this account")
+		r.Short = "?"
+		return r
+	}
+
+	if status != http.StatusOK {
+		r.Error = fmt.Errorf("HTTP %d", status)
+		r.Short = "?"
+		return r
+	}
+
+	r.Identity = user.Login
+	r.Plan = user.CopilotPlan
+	r.Windows = copilotWindows(user)
+	r.Class = "normal"
+
+	if len(r.Windows) > 0 {
+		r.Short = fmt.Sprintf("%.0f%%", r.Windows[0].UsedPct)
+		r.Class = classFromPct(r.Windows[0].UsedPct)
+	} else {
+		r.Short = "∞"
+	}
+
+	return r
+}
+
+func copilotWindows(user copilotUserResponse) []RateWindow {
+	resetAt, hasReset := copilotResetTime(user)
+
+	var windows []RateWindow
+	for _, q := range copilotQuotaLabels {
+		snap, ok := user.QuotaSnapshots[q.key]
+		if !ok || snap.Unlimited {
+			continue
+		}
+
+		usedPct := 100 - snap.PercentRemaining
+		if snap.Entitlement > 0 {
+			usedPct = (snap.Entitlement - snap.Remaining) / snap.Entitlement * 100
+		}
+
+		windows = append(windows, RateWindow{
+			Label:    q.label,
+			UsedPct:  usedPct,
+			ResetAt:  resetAt,
+			HasReset: hasReset,
+		})
+	}
+	return windows
+}
+
+func copilotResetTime(user copilotUserResponse) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, user.QuotaResetDateUTC); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse("2006-01-02", user.QuotaResetDate); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+func fetchCopilotUser(ctx context.Context, token string) (copilotUserResponse, int, error) {
+	var user copilotUserResponse
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointURL("copilot")+"/copilot_internal/user", nil)
+	if err != nil {
+		return user, 0, err
+	}
+	req.Header.Set("Authorization", "token "+token)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Editor-Version", copilotEditorVersion)
+	req.Header.Set("X-GitHub-Api-Version", copilotAPIVersion)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return user, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return user, resp.StatusCode, nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return user, 0, err
+	}
+
+	return user, http.StatusOK, nil
+}
+
+// loadCopilotToken prefers the OAuth token written by the Copilot editor
+// plugins and falls back to the gh CLI's token.
+func loadCopilotToken(ctx context.Context) (string, error) {
+	if replaying() {
+		return replayCredential, nil
+	}
+	if token, err := loadCopilotEditorToken(); err == nil && token != "" {
+		return token, nil
+	}
+
+	token, err := ghAuthToken(ctx)
+	if err != nil || token == "" {
+		return "", fmt.Errorf("no Copilot token found; sign in to Copilot in your editor or run `gh auth login`")
+	}
+	return token, nil
+}
+
+func loadCopilotEditorToken() (string, error) {
+	dir, err := userConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	for _, name := range []string{"apps.json", "hosts.json"} {
+		data, err := os.ReadFile(filepath.Join(dir, "github-copilot", name))
+		if err != nil {
+			continue
+		}
+
+		var entries map[string]struct {
+			OAuthToken string `json:"oauth_token"`
+		}
+		if err := json.Unmarshal(bytes.TrimSpace(data), &entries); err != nil {
+			continue
+		}
+
+		// Prefer github.com entries, in a stable order.
+		keys := make([]string, 0, len(entries))
+		for k := range entries {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			gi, gj := strings.HasPrefix(keys[i], "github.com"), strings.HasPrefix(keys[j], "github.com")
+			if gi != gj {
+				return gi
+			}
+			return keys[i] < keys[j]
+		})
+		for _, k := range keys {
+			if entries[k].OAuthToken != "" {
+				return entries[k].OAuthToken, nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("no Copilot editor token found")
+}
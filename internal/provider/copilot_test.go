@@ -0,0 +1,158 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func withGHAuthToken(t *testing.T, fn func(ctx context.Context) (string, error)) {
+	t.Helper()
+	old := ghAuthToken
+	ghAuthToken = fn
+	t.Cleanup(func() {
+		ghAuthToken = old
+	})
+}
+
+func writeCopilotConfig(t *testing.T, name, body string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	path := filepath.Join(dir, "github-copilot", name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+}
+
+func TestCopilotConfigured(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("AI_USAGE_BAR_COPILOT", "")
+
+	hosts := filepath.Join(dir, "gh", "hosts.yml")
+	if err := os.MkdirAll(filepath.Dir(hosts), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(hosts, []byte("github.com:\n    user: octo\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if (Copilot{}).Configured() {
+		t.Fatal("a gh login alone should not enable Copilot")
+	}
+
+	t.Setenv("AI_USAGE_BAR_COPILOT", "1")
+	if !(Copilot{}).Configured() {
+		t.Fatal("expected AI_USAGE_BAR_COPILOT=1 to enable Copilot")
+	}
+
+	t.Setenv("AI_USAGE_BAR_COPILOT", "")
+	writeCopilotConfig(t, "hosts.json", `{"github.com":{"oauth_token":"gho_editor"}}`)
+	if !(Copilot{}).Configured() {
+		t.Fatal("expected an editor login to enable Copilot")
+	}
+}
+
+func TestLoadCopilotTokenPrefersEditorConfig(t *testing.T) {
+	writeCopilotConfig(t, "apps.json", `{"github.com:Iv1.abc":{"user":"octo","oauth_token":"gho_editor"}}`)
+	withGHAuthToken(t, func(ctx context.Context) (string, error) {
+		t.Fatal("gh should not be consulted when an editor token exists")
+		return "", nil
+	})
+
+	token, err := loadCopilotToken(context.Background())
+	if err != nil || token != "gho_editor" {
+		t.Fatalf("expected editor token, got %q (%v)", token, err)
+	}
+}
+
+func TestLoadCopilotTokenFallsBackToGH(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	withGHAuthToken(t, func(ctx context.Context) (string, error) {
+		return "gho_cli", nil
+	})
+
+	token, err := loadCopilotToken(context.Background())
+	if err != nil || token != "gho_cli" {
+		t.Fatalf("expected gh token, got %q (%v)", token, err)
+	}
+}
+
+func TestCopilotFetchRequiresToken(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	withGHAuthToken(t, func(ctx context.Context) (string, error) {
+		return "", errors.New("gh not installed")
+	})
+
+	r := Copilot{}.Fetch(context.Background())
+	if r.Error == nil || r.Short != "?" {
+		t.Fatalf("expected missing token error with '?', got %q (%v)", r.Short, r.Error)
+	}
+}
+
+func TestCopilotFetchPremiumRequests(t *testing.T) {
+	resetEndpoints(t)
+	writeCopilotConfig(t, "hosts.json", `{"github.com":{"oauth_token":"gho_editor"}}`)
+
+	withMockDefaultClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.URL.String() != "https://api.github.com/copilot_internal/user" {
+			t.Fatalf("unexpected URL: %s", req.URL.String())
+		}
+		if req.Header.Get("Authorization") != "token gho_editor" {
+			t.Fatalf("missing auth header: %q", req.Header.Get("Authorization"))
+		}
+		body := `{
+		  "login": "octo",
+		  "copilot_plan": "individual_pro",
+		  "quota_reset_date": "2026-04-01",
+		  "quota_snapshots": {
+		    "premium_interactions": {"entitlement": 300, "remaining": 30, "percent_remaining": 10, "unlimited": false},
+		    "chat": {"entitlement": 0, "remaining": 0, "percent_remaining": 100, "unlimited": true}
+		  }
+		}`
+		return jsonResponse(http.StatusOK, body), nil
+	})
+
+	r := Copilot{}.Fetch(context.Background())
+
+	if r.Error != nil {
+		t.Fatalf("expected success, got error: %v", r.Error)
+	}
+	if r.Identity != "octo" || r.Plan != "individual_pro" {
+		t.Fatalf("unexpected identity/plan: %q/%q", r.Identity, r.Plan)
+	}
+	if len(r.Windows) != 1 || r.Windows[0].Label != "Premium (monthly)" {
+		t.Fatalf("expected only the limited premium window, got %#v", r.Windows)
+	}
+	if !r.Windows[0].HasReset || !r.Windows[0].ResetAt.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected reset: %#v", r.Windows[0])
+	}
+	if r.Short != "90%" || r.Class != "critical" {
+		t.Fatalf("unexpected short/class: %q/%q", r.Short, r.Class)
+	}
+}
+
+func TestCopilotFetchAuthFailure(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	withGHAuthToken(t, func(ctx context.Context) (string, error) {
+		return "gho_cli", nil
+	})
+	withMockDefaultClient(t, func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusUnauthorized, `{}`), nil
+	})
+
+	r := Copilot{}.Fetch(context.Background())
+	if r.Short != "!" || r.Error == nil || !strings.Contains(r.Error.Error(), "copilot auth failed") {
+		t.Fatalf("expected auth failure, got %q (%v)", r.Short, r.Error)
+	}
+}
@@ -0,0 +1,284 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Cursor reports Cursor's fast-request usage and usage-based spend for the
+// current billing period. BaseURL overrides the dashboard host, e.g. to
+// point at a local stub; when empty CURSOR_BASE_URL or cursor.com is used.
+type Cursor struct {
+	BaseURL string
+}
+
+func (Cursor) Name() string { return "Cursor" }
+
+// Configured reports whether CURSOR_SESSION_TOKEN is set or Cursor's state
+// database exists. Reading the database needs the sqlite3 CLI on PATH; when
+// it is missing the provider is still listed and Fetch says so.
+func (Cursor) Configured() bool {
+	if os.Getenv("CURSOR_SESSION_TOKEN") != "" {
+		return true
+	}
+	path, err := cursorStatePath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+type cursorModelUsage struct {
+	NumRequests     int  `json:"numRequests"`
+	MaxRequestUsage *int `json:"maxRequestUsage"`
+}
+
+type cursorInvoiceResponse struct {
+	Items []struct {
+		Description string  `json:"description"`
+		Cents       float64 `json:"cents"`
+	} `json:"items"`
+}
+
+type cursorMeResponse struct {
+	Email string `json:"email"`
+}
+
+type cursorStripeResponse struct {
+	MembershipType string `json:"membershipType"`
+}
+
+const (
+	cursorBaseURL          = "https://cursor.com"
+	cursorAccessTokenKey   = "cursorAuth/accessToken"
+	cursorFastRequestModel = "gpt-4"
+	cursorAuthFailedError  = "cursor session expired; sign in to Cursor again"
+)
+
+// cursorStateValue reads a key from Cursor's state database. Go's standard
+// library has no SQLite driver, so this shells out to the sqlite3 CLI; it
+// is a variable so tests can stub it.
+var cursorStateValue = func(ctx context.Context, dbPath, key string) (string, error) {
+	query := fmt.Sprintf("SELECT value FROM ItemTable WHERE key = '%s';", strings.ReplaceAll(key, "'", "''"))
+	out, err := exec.CommandContext(ctx, "sqlite3", "-readonly", dbPath, query).Output()
+	if err != nil {
+		return "", fmt.Errorf("read cursor state (is sqlite3 installed?): %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (c Cursor) Fetch(ctx context.Context) Result {
+	return c.fetch(ctx, time.Now())
+}
+
+func (c Cursor) fetch(ctx context.Context, now time.Time) Result {
+	r := Result{Name: "Cursor"}
+
+	token, err := loadCursorToken(ctx)
+	if err != nil {
+		r.Error = err
+		r.Short = "?"
+		return r
+	}
+
+	// A replayed session token is a placeholder rather than a JWT, and the
+	// tape blanks the user id in the URLs it matches.
+	userID := replayCredential
+	if !replaying() {
+		userID = cursorUserID(token)
+	}
+	if userID == "" {
+		r.Error = fmt.Errorf("cursor session token has no user id")
+		r.Short = "?"
+		return r
+	}
+	cookie := "WorkosCursorSessionToken=" + url.QueryEscape(userID+"::"+token)
+
+	var usage map[string]json.RawMessage
+	status, err := c.do(ctx, http.MethodGet, "/api/usage?user="+url.QueryEscape(userID), cookie, nil, &usage)
+	if err != nil {
+		r.Error = err
+		r.Short = "?"
+		return r
+	}
+	if status == http.StatusUnauthorized || status == http.StatusForbidden {
+		r.Error = fmt.Errorf("%s (HTTP %d)", cursorAuthFailedError, status)
+		r.Short = "!"
+		return r
+	}
+	if status != http.StatusOK {
+		r.Error = fmt.Errorf("HTTP %d", status)
+		r.Short = "?"
+		return r
+	}
+
+	periodStart, hasPeriod := now, false
+	if raw, ok := usage["startOfMonth"]; ok {
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			if t, err := time.Parse(time.RFC3339, s); err == nil {
+				periodStart, hasPeriod = t, true
+			}
+		}
+	}
+
+	r.Class = "normal"
+	if raw, ok := usage[cursorFastRequestModel]; ok {
+		var fast cursorModelUsage
+		if err := json.Unmarshal(raw, &fast); err == nil && fast.MaxRequestUsage != nil && *fast.MaxRequestUsage > 0 {
+			usedPct := float64(fast.NumRequests) / float64(*fast.MaxRequestUsage) * 100
+			r.Windows = append(r.Windows, RateWindow{
+				Label:    "Fast requests",
+				UsedPct:  usedPct,
+				ResetAt:  periodStart.AddDate(0, 1, 0),
+				HasReset: hasPeriod,
+			})
+			r.Short = fmt.Sprintf("%.0f%%", usedPct)
+			r.Class = classFromPct(usedPct)
+		}
+	}
+
+	var invoice cursorInvoiceResponse
+	body := map[string]any{
+		"month":              int(periodStart.Month()),
+		"year":               periodStart.Year(),
+		"includeUsageEvents": false,
+	}
+	if status, err := c.do(ctx, http.MethodPost, "/api/dashboard/get-monthly-invoice", cookie, body, &invoice); err == nil && status == http.StatusOK {
+		var cents float64
+		for _, item := range invoice.Items {
+			cents += item.Cents
+		}
+		r.Spend = append(r.Spend, SpendEntry{Label: "Usage-based (this period)", Amount: cents / 100})
+		if r.Short == "" {
+			r.Short = fmt.Sprintf("$%.2f", cents/100)
+		}
+	}
+
+	var me cursorMeResponse
+	if status, err := c.do(ctx, http.MethodGet, "/api/auth/me", cookie, nil, &me); err == nil && status == http.StatusOK {
+		r.Identity = me.Email
+	}
+
+	var stripe cursorStripeResponse
+	if status, err := c.do(ctx, http.MethodGet, "/api/auth/stripe", cookie, nil, &stripe); err == nil && status == http.StatusOK {
+		r.Plan = stripe.MembershipType
+	}
+
+	if r.Short == "" {
+		r.Error = fmt.Errorf("no fast-request or usage-based data for this period")
+		r.Short = "?"
+	}
+
+	return r
+}
+
+func (c Cursor) do(ctx context.Context, method, path, cookie string, body any, out any) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL()+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Cookie", cookie)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", c.baseURL())
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return 0, err
+	}
+	return http.StatusOK, nil
+}
+
+func (c Cursor) baseURL() string {
+	base := c.BaseURL
+	if base == "" {
+		base = os.Getenv("CURSOR_BASE_URL")
+	}
+	if base == "" {
+		base = endpointURL("cursor")
+	}
+	return strings.TrimRight(base, "/")
+}
+
+// loadCursorToken returns CURSOR_SESSION_TOKEN if set, otherwise the access
+// token from Cursor's local state database.
+func loadCursorToken(ctx context.Context) (string, error) {
+	if token := credential(os.Getenv("CURSOR_SESSION_TOKEN")); token != "" {
+		return token, nil
+	}
+
+	path, err := cursorStatePath()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", err
+	}
+
+	token, err := cursorStateValue(ctx, path, cursorAccessTokenKey)
+	if err != nil {
+		return "", err
+	}
+	token = strings.Trim(token, `"`)
+	if token == "" {
+		return "", fmt.Errorf("no Cursor session found; sign in to Cursor")
+	}
+	return token, nil
+}
+
+// cursorUserID extracts the user id from the session JWT's subject, e.g.
+// "auth0|user_01ABC" -> "user_01ABC". The token is not verified.
+func cursorUserID(token string) string {
+	var claims struct {
+		Sub string `json:"sub"`
+	}
+	if err := decodeJWTClaims(token, &claims); err != nil {
+		return ""
+	}
+
+	if i := strings.LastIndex(claims.Sub, "|"); i >= 0 {
+		return claims.Sub[i+1:]
+	}
+	return claims.Sub
+}
+
+func cursorStatePath() (string, error) {
+	dir, err := userConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "Cursor", "User", "globalStorage", "state.vscdb"), nil
+}
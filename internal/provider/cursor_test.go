@@ -0,0 +1,191 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func cursorTestToken(sub string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"` + sub + `"}`))
+	return "eyJhbGciOiJIUzI1NiJ9." + payload + ".sig"
+}
+
+func TestCursorUserID(t *testing.T) {
+	if got := cursorUserID(cursorTestToken("auth0|user_01ABC")); got != "user_01ABC" {
+		t.Fatalf("unexpected user id: %q", got)
+	}
+	if got := cursorUserID("garbage"); got != "" {
+		t.Fatalf("expected empty user id for malformed token, got %q", got)
+	}
+}
+
+func TestLoadCursorTokenFromStateDB(t *testing.T) {
+	t.Setenv("CURSOR_SESSION_TOKEN", "")
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	path := filepath.Join(dir, "Cursor", "User", "globalStorage", "state.vscdb")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatalf("write db: %v", err)
+	}
+
+	old := cursorStateValue
+	cursorStateValue = func(ctx context.Context, dbPath, key string) (string, error) {
+		if dbPath != path || key != cursorAccessTokenKey {
+			t.Fatalf("unexpected lookup %s %s", dbPath, key)
+		}
+		return `"from-db"`, nil
+	}
+	t.Cleanup(func() { cursorStateValue = old })
+
+	token, err := loadCursorToken(context.Background())
+	if err != nil || token != "from-db" {
+		t.Fatalf("expected token from state db, got %q (%v)", token, err)
+	}
+}
+
+func TestCursorFetchUsageAndSpend(t *testing.T) {
+	token := cursorTestToken("auth0|user_01ABC")
+	t.Setenv("CURSOR_SESSION_TOKEN", token)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		cookie, err := req.Cookie("WorkosCursorSessionToken")
+		if err != nil {
+			t.Fatalf("missing session cookie: %v", err)
+		}
+		if v, _ := url.QueryUnescape(cookie.Value); v != "user_01ABC::"+token {
+			t.Fatalf("unexpected cookie value: %q", v)
+		}
+
+		switch req.URL.Path {
+		case "/api/usage":
+			if req.URL.Query().Get("user") != "user_01ABC" {
+				t.Fatalf("unexpected user query: %q", req.URL.RawQuery)
+			}
+			writeJSON(w, `{"gpt-4":{"numRequests":400,"maxRequestUsage":500},"startOfMonth":"2026-02-15T10:00:00.000Z"}`)
+		case "/api/dashboard/get-monthly-invoice":
+			var body map[string]any
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				t.Fatalf("decode invoice body: %v", err)
+			}
+			if body["month"] != float64(2) || body["year"] != float64(2026) {
+				t.Fatalf("unexpected invoice period: %#v", body)
+			}
+			writeJSON(w, `{"items":[{"description":"claude-4-opus","cents":1250},{"description":"gpt-5","cents":250}]}`)
+		case "/api/auth/me":
+			writeJSON(w, `{"email":"dev@example.com"}`)
+		case "/api/auth/stripe":
+			writeJSON(w, `{"membershipType":"pro"}`)
+		default:
+			t.Fatalf("unexpected path: %s", req.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	r := Cursor{BaseURL: srv.URL}.fetch(context.Background(), time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	if r.Error != nil {
+		t.Fatalf("expected success, got error: %v", r.Error)
+	}
+	if r.Short != "80%" || r.Class != "warning" {
+		t.Fatalf("unexpected short/class: %q/%q", r.Short, r.Class)
+	}
+	if len(r.Windows) != 1 || !r.Windows[0].ResetAt.Equal(time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected windows: %#v", r.Windows)
+	}
+	if len(r.Spend) != 1 || r.Spend[0].Amount != 15 {
+		t.Fatalf("unexpected spend: %#v", r.Spend)
+	}
+	if r.Identity != "dev@example.com" || r.Plan != "pro" {
+		t.Fatalf("unexpected identity/plan: %q/%q", r.Identity, r.Plan)
+	}
+}
+
+func TestCursorFetchAuthFailure(t *testing.T) {
+	t.Setenv("CURSOR_SESSION_TOKEN", cursorTestToken("user_1"))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	r := Cursor{BaseURL: srv.URL}.Fetch(context.Background())
+	if r.Short != "!" || r.Error == nil {
+		t.Fatalf("expected auth failure, got %q (%v)", r.Short, r.Error)
+	}
+}
+
+func TestCursorFetchWithoutUsageData(t *testing.T) {
+	t.Setenv("CURSOR_SESSION_TOKEN", cursorTestToken("user_1"))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/api/usage":
+			writeJSON(w, `{"startOfMonth":"2026-02-15T10:00:00.000Z"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	r := Cursor{BaseURL: srv.URL}.Fetch(context.Background())
+	if r.Short != "?" || r.Error == nil {
+		t.Fatalf("expected an error for missing data, got %q (%v)", r.Short, r.Error)
+	}
+}
+
+func TestCursorRecordThenReplay(t *testing.T) {
+	t.Cleanup(stopTape)
+	t.Setenv("CURSOR_SESSION_TOKEN", cursorTestToken("auth0|user_01ABC"))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/api/usage":
+			writeJSON(w, `{"gpt-4":{"numRequests":100,"maxRequestUsage":500},"startOfMonth":"2026-02-15T10:00:00.000Z"}`)
+		case "/api/auth/me":
+			writeJSON(w, `{"email":"dev@example.com"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	c := Cursor{BaseURL: srv.URL}
+
+	dir := t.TempDir()
+	if err := Record(dir); err != nil {
+		t.Fatal(err)
+	}
+	live := c.Fetch(context.Background())
+	if live.Error != nil || live.Short != "20%" {
+		t.Fatalf("unexpected live result: %+v", live)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	for _, f := range files {
+		data, _ := os.ReadFile(f)
+		if strings.Contains(string(data), "user_01ABC") {
+			t.Fatalf("%s leaks the user id:\n%s", filepath.Base(f), data)
+		}
+	}
+
+	srv.Close()
+	t.Setenv("CURSOR_SESSION_TOKEN", "")
+	if err := Replay(dir); err != nil {
+		t.Fatal(err)
+	}
+	replayed := c.Fetch(context.Background())
+	if replayed.Error != nil || replayed.Short != live.Short {
+		t.Fatalf("replay differs from the recording: %+v vs %+v", replayed, live)
+	}
+}
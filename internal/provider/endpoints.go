@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// endpointDefaults are the built-in base URLs that can be pointed
+// elsewhere, e.g. at an enterprise gateway or a local stand-in server.
+// Keys name them in the config file's "endpoints" section and, upper-cased,
+// in AI_USAGE_BAR_<KEY>_URL.
+var endpointDefaults = map[string]string{
+	"claude":          claudeOAuthBaseURL,
+	"claude_token":    claudeTokenURL,
+	"codex":           codexBackendBaseURL,
+	"codex_token":     codexTokenURL,
+	"openrouter":      openRouterBaseURL,
+	"gemini":          geminiCodeAssistBaseURL,
+	"gemini_token":    geminiTokenURL,
+	"copilot":         copilotBaseURL,
+	"anthropic_admin": anthropicAdminBaseURL,
+	"openai_admin":    openAIAdminBaseURL,
+	"cursor":          cursorBaseURL,
+	"deepseek":        deepSeekBaseURL,
+	"moonshot":        moonshotBaseURL,
+	"siliconflow":     siliconFlowBaseURL,
+}
+
+// endpointVendorEnv lists the older per-vendor variables, which still take
+// precedence over the generic ones.
+var endpointVendorEnv = map[string]string{
+	"anthropic_admin": "ANTHROPIC_ADMIN_BASE_URL",
+	"openai_admin":    "OPENAI_ADMIN_BASE_URL",
+	"cursor":          "CURSOR_BASE_URL",
+	"deepseek":        "DEEPSEEK_BASE_URL",
+	"moonshot":        "MOONSHOT_BASE_URL",
+	"siliconflow":     "SILICONFLOW_BASE_URL",
+}
+
+// endpointOverrides holds the config file's "endpoints" section.
+var endpointOverrides struct {
+	sync.RWMutex
+	m map[string]string
+}
+
+// ConfigureEndpoints applies the config file's endpoint overrides. Default
+// calls it; unknown keys are an error.
+func ConfigureEndpoints(overrides map[string]string) error {
+	for key := range overrides {
+		if _, ok := endpointDefaults[key]; !ok {
+			return fmt.Errorf("unknown endpoint %q in config", key)
+		}
+	}
+
+	endpointOverrides.Lock()
+	endpointOverrides.m = overrides
+	endpointOverrides.Unlock()
+	return nil
+}
+
+func endpointEnv(key string) string {
+	return "AI_USAGE_BAR_" + strings.ToUpper(key) + "_URL"
+}
+
+// endpointURL returns the base URL for key, without a trailing slash:
+// AI_USAGE_BAR_<KEY>_URL if set, else the config file's override, else the
+// built-in default.
+func endpointURL(key string) string {
+	url := os.Getenv(endpointEnv(key))
+	if url == "" {
+		endpointOverrides.RLock()
+		url = endpointOverrides.m[key]
+		endpointOverrides.RUnlock()
+	}
+	if url == "" {
+		url = endpointDefaults[key]
+	}
+	return strings.TrimRight(url, "/")
+}
+
+// EndpointOverride is a built-in endpoint that has been pointed elsewhere.
+type EndpointOverride struct {
+	Key    string
+	URL    string
+	Source string // the environment variable, or "config"
+}
+
+// EndpointOverrides lists the active overrides, sorted by key.
+func EndpointOverrides() []EndpointOverride {
+	var out []EndpointOverride
+	for key := range endpointDefaults {
+		o := EndpointOverride{Key: key}
+		switch {
+		case endpointVendorEnv[key] != "" && os.Getenv(endpointVendorEnv[key]) != "":
+			o.Source = endpointVendorEnv[key]
+			o.URL = os.Getenv(o.Source)
+		case os.Getenv(endpointEnv(key)) != "":
+			o.Source = endpointEnv(key)
+			o.URL = os.Getenv(o.Source)
+		default:
+			endpointOverrides.RLock()
+			o.URL = endpointOverrides.m[key]
+			endpointOverrides.RUnlock()
+			o.Source = "config"
+		}
+		if o.URL != "" {
+			out = append(out, o)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
@@ -0,0 +1,114 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// resetEndpoints puts every endpoint back to its built-in default for the
+// test, clearing config and environment overrides.
+func resetEndpoints(t *testing.T) {
+	t.Helper()
+	_ = ConfigureEndpoints(nil)
+	t.Cleanup(func() { _ = ConfigureEndpoints(nil) })
+	for key := range endpointDefaults {
+		t.Setenv(endpointEnv(key), "")
+	}
+	for _, env := range endpointVendorEnv {
+		t.Setenv(env, "")
+	}
+}
+
+func TestEndpointURLPrecedence(t *testing.T) {
+	resetEndpoints(t)
+
+	if got := endpointURL("claude"); got != "https://api.anthropic.com/api/oauth" {
+		t.Fatalf("expected the default, got %q", got)
+	}
+
+	if err := ConfigureEndpoints(map[string]string{"claude": "https://gateway.corp/claude/"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := endpointURL("claude"); got != "https://gateway.corp/claude" {
+		t.Fatalf("expected the config override without a trailing slash, got %q", got)
+	}
+
+	t.Setenv("AI_USAGE_BAR_CLAUDE_URL", "http://localhost:9000")
+	if got := endpointURL("claude"); got != "http://localhost:9000" {
+		t.Fatalf("expected the environment to win, got %q", got)
+	}
+}
+
+func TestConfigureEndpointsRejectsUnknownKeys(t *testing.T) {
+	resetEndpoints(t)
+
+	if err := ConfigureEndpoints(map[string]string{"claud": "https://gateway.corp"}); err == nil {
+		t.Fatal("expected unknown endpoint error")
+	}
+}
+
+func TestEndpointDefaultsMatchProviders(t *testing.T) {
+	resetEndpoints(t)
+
+	// Every built-in that reads its base URL through a key must find one.
+	for _, p := range []Provider{Claude{}, Codex{}, OpenRouter{}, Gemini{}, Copilot{}, AnthropicAdmin{}, OpenAI{}, Cursor{}, DeepSeek(), Moonshot(), SiliconFlow()} {
+		if Endpoint(p) == "" {
+			t.Fatalf("%s has no endpoint", p.Name())
+		}
+	}
+	if got := Endpoint(Claude{}); got != "https://api.anthropic.com/api/oauth/usage" {
+		t.Fatalf("unexpected Claude endpoint %q", got)
+	}
+	if got := Endpoint(Codex{}); got != "https://chatgpt.com/backend-api/wham/usage" {
+		t.Fatalf("unexpected Codex endpoint %q", got)
+	}
+}
+
+func TestEndpointOverrides(t *testing.T) {
+	resetEndpoints(t)
+
+	if got := EndpointOverrides(); len(got) != 0 {
+		t.Fatalf("expected no overrides, got %+v", got)
+	}
+
+	t.Setenv("AI_USAGE_BAR_OPENROUTER_URL", "http://localhost:8081/api/v1")
+	t.Setenv("DEEPSEEK_BASE_URL", "https://deepseek.corp")
+	if err := ConfigureEndpoints(map[string]string{"codex": "https://gateway.corp/codex"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []EndpointOverride{
+		{Key: "codex", URL: "https://gateway.corp/codex", Source: "config"},
+		{Key: "deepseek", URL: "https://deepseek.corp", Source: "DEEPSEEK_BASE_URL"},
+		{Key: "openrouter", URL: "http://localhost:8081/api/v1", Source: "AI_USAGE_BAR_OPENROUTER_URL"},
+	}
+	if got := EndpointOverrides(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected overrides:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestFetchClaudeUsageUsesOverriddenEndpoint(t *testing.T) {
+	resetEndpoints(t)
+
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		path = req.URL.Path
+		writeJSON(w, `{"five_hour":{"utilization":7}}`)
+	}))
+	defer srv.Close()
+	t.Setenv("AI_USAGE_BAR_CLAUDE_URL", srv.URL+"/anthropic/oauth")
+
+	usage, status, err := fetchClaudeUsage(context.Background(), "token123")
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("unexpected result: %d, %v", status, err)
+	}
+	if path != "/anthropic/oauth/usage" {
+		t.Fatalf("unexpected path %q", path)
+	}
+	if usage.FiveHour == nil || usage.FiveHour.Utilization != 7 {
+		t.Fatalf("unexpected usage payload: %#v", usage)
+	}
+}
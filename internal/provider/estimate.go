@@ -0,0 +1,40 @@
+package provider
+
+import (
+	"github.com/jhartzell/ai-usage-bar/internal/config"
+	"github.com/jhartzell/ai-usage-bar/internal/pricing"
+)
+
+// loadPricing returns the built-in price table with any overrides from the
+// config file applied. A broken config falls back to the built-in prices;
+// the config error itself is surfaced by Default.
+func loadPricing() pricing.Table {
+	table := pricing.Default()
+	cfg, err := config.Load()
+	if err != nil {
+		return table
+	}
+	return table.With(cfg.Pricing)
+}
+
+// estimateSpend converts locally counted token periods into API-equivalent
+// cost rows. Models without a known price are left out.
+func estimateSpend(periods []TokenPeriod, table pricing.Table) []SpendEntry {
+	var entries []SpendEntry
+	for _, p := range periods {
+		var cost float64
+		priced := false
+		for _, m := range p.Models {
+			price, ok := table.Lookup(m.Label)
+			if !ok {
+				continue
+			}
+			cost += price.Cost(m.Input, m.Output, m.CacheWrite, m.CacheRead)
+			priced = true
+		}
+		if priced {
+			entries = append(entries, SpendEntry{Label: p.Label + " (API est.)", Amount: cost, Estimate: true})
+		}
+	}
+	return entries
+}
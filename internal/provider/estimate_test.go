@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jhartzell/ai-usage-bar/internal/pricing"
+)
+
+func TestEstimateSpend(t *testing.T) {
+	periods := []TokenPeriod{
+		{
+			Label: "Today",
+			Models: []TokenRow{
+				{Label: "claude-sonnet-4-5-20250929", TokenCount: TokenCount{Input: 1_000_000, Output: 100_000}},
+				{Label: "unknown-model", TokenCount: TokenCount{Input: 5_000_000}},
+			},
+		},
+		{
+			Label:  "Weekly (7d)",
+			Models: []TokenRow{{Label: "unknown-model", TokenCount: TokenCount{Input: 1}}},
+		},
+	}
+
+	got := estimateSpend(periods, pricing.Default())
+	if len(got) != 1 {
+		t.Fatalf("expected only the priced period, got %+v", got)
+	}
+	if got[0].Label != "Today (API est.)" || !got[0].Estimate || !approxEqual(got[0].Amount, 4.5) {
+		t.Fatalf("unexpected estimate: %+v", got[0])
+	}
+}
+
+func TestLoadPricingAppliesConfigOverrides(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	if err := os.MkdirAll(filepath.Join(dir, "ai-usage-bar"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := `{"pricing":{"claude-sonnet-4":{"input":1,"output":1,"cache_write":1,"cache_read":1}}}`
+	if err := os.WriteFile(filepath.Join(dir, "ai-usage-bar", "config.json"), []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	p, ok := loadPricing().Lookup("claude-sonnet-4-5")
+	if !ok || p.Input != 1 || p.CacheRead != 1 {
+		t.Fatalf("expected config override, got %+v %v", p, ok)
+	}
+	if p, _ := loadPricing().Lookup("claude-opus-4-1"); p.Input != 15 {
+		t.Fatalf("expected built-in price for other models, got %+v", p)
+	}
+}
+
+func TestClaudeFetchEstimatesDespiteError(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	dir := filepath.Join(home, ".claude", "projects", "-repo")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	line := `{"type":"assistant","timestamp":"` + time.Now().UTC().Format(time.RFC3339) + `","requestId":"req_1",` +
+		`"message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":1000000,"output_tokens":0}}}` + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "session.jsonl"), []byte(line), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// No credentials: the API call fails, but the local tokens still count.
+	r := Claude{}.Fetch(context.Background())
+	if r.Error == nil {
+		t.Fatal("expected an error without credentials")
+	}
+	if len(r.Spend) == 0 || !r.Spend[0].Estimate || !approxEqual(r.Spend[0].Amount, 3) {
+		t.Fatalf("expected estimates alongside the error, got %+v", r.Spend)
+	}
+}
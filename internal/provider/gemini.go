@@ -0,0 +1,397 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+type Gemini struct{}
+
+func (Gemini) Name() string { return "Gemini" }
+
+func (Gemini) Configured() bool {
+	path, err := geminiCredentialsPath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+type geminiCredentials struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	IDToken      string `json:"id_token"`
+	ExpiryDate   int64  `json:"expiry_date"`
+}
+
+type geminiRefreshResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	IDToken      string `json:"id_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+type geminiLoadResponse struct {
+	CurrentTier *struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"currentTier"`
+	Project string `json:"cloudaicompanionProject"`
+}
+
+type geminiQuotaResponse struct {
+	Buckets []geminiQuotaBucket `json:"buckets"`
+}
+
+type geminiQuotaBucket struct {
+	RemainingFraction *float64 `json:"remainingFraction"`
+	ResetTime         string   `json:"resetTime"`
+	TokenType         string   `json:"tokenType"`
+	ModelID           string   `json:"modelId"`
+}
+
+const (
+	geminiCodeAssistBaseURL = "https://cloudcode-pa.googleapis.com/v1internal"
+	geminiTokenURL          = "https://oauth2.googleapis.com/token"
+	geminiOAuthClientID     = "681255809395-oo8ft2oprdrnp9e3aqf6av3hmdib135j.apps.googleusercontent.com"
+	geminiOAuthSecret       = "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" // installed-app secret published with Gemini CLI
+	geminiAuthFailedError   = "gemini auth expired; run `gemini` to log in"
+)
+
+func (g Gemini) Fetch(ctx context.Context) Result {
+	r := Result{Name: "Gemini"}
+
+	creds, err := loadGeminiCredentials()
+	if err != nil {
+		r.Error = err
+		r.Short = "?"
+		return r
+	}
+
+	r.Identity = geminiEmail(creds.IDToken)
+
+	if geminiTokenExpired(creds) {
+		if err := refreshGeminiAuth(ctx, creds); err == nil {
+			_ = saveGeminiCredentials(creds)
+		}
+	}
+
+	load, status, err := loadGeminiCodeAssist(ctx, creds.AccessToken)
+	if err != nil {
+		r.Error = err
+		r.Short = "?"
+		return r
+	}
+
+	if isGeminiAuthStatus(status) {
+		if err := refreshGeminiAuth(ctx, creds); err != nil {
+			r.Error = fmt.Errorf("%s (%v)", geminiAuthFailedError, err)
+			r.Short = "!"
+			return r
+		}
+
+		if err := saveGeminiCredentials(creds); err != nil {
+			r.Error = fmt.Errorf("gemini token refresh succeeded, but failed to save updated tokens: %w", err)
+			r.Short = "!"
+			return r
+		}
+
+		load, status, err = loadGeminiCodeAssist(ctx, creds.AccessToken)
+		if err != nil {
+			r.Error = err
+			r.Short = "?"
+			return r
+		}
+	}
+
+	if isGeminiAuthStatus(status) {
+		r.Error = fmt.Errorf("%s (HTTP %d)", geminiAuthFailedError, status)
+		r.Short = "!"
+		return r
+	}
+
+	if status != http.StatusOK {
+		r.Error = fmt.Errorf("HTTP %d", status)
+		r.Short = "?"
+		return r
+	}
+
+	if load.CurrentTier != nil {
+		r.Plan = load.CurrentTier.Name
+		if r.Plan == "" {
+			r.Plan = load.CurrentTier.ID
+		}
+	}
+
+	project := os.Getenv("GOOGLE_CLOUD_PROJECT")
+	if project == "" {
+		project = load.Project
+	}
+
+	var quota geminiQuotaResponse
+	status, err = postGeminiJSON(ctx, endpointURL("gemini")+":retrieveUserQuota", creds.AccessToken, map[string]string{"project": project}, &quota)
+	if err != nil {
+		r.Error = err
+		r.Short = "?"
+		return r
+	}
+	if status != http.StatusOK {
+		r.Error = fmt.Errorf("quota HTTP %d", status)
+		r.Short = "?"
+		return r
+	}
+
+	r.Windows = geminiWindows(quota.Buckets)
+	if len(r.Windows) == 0 {
+		r.Error = fmt.Errorf("no quota buckets reported")
+		r.Short = "?"
+		return r
+	}
+
+	r.Class = "normal"
+	worst := 0.0
+	for _, w := range r.Windows {
+		if w.UsedPct >= worst {
+			worst = w.UsedPct
+		}
+	}
+	r.Short = fmt.Sprintf("%.0f%%", worst)
+	r.Class = classFromPct(worst)
+
+	return r
+}
+
+// geminiWindows converts per-model quota buckets to daily rate windows,
+// sorted by model so the card order is stable.
+func geminiWindows(buckets []geminiQuotaBucket) []RateWindow {
+	sort.SliceStable(buckets, func(i, j int) bool { return buckets[i].ModelID < buckets[j].ModelID })
+
+	windows := make([]RateWindow, 0, len(buckets))
+	for _, b := range buckets {
+		if b.RemainingFraction == nil {
+			continue
+		}
+
+		label := b.ModelID
+		if label == "" {
+			label = "Daily"
+		}
+		if b.TokenType != "" && b.TokenType != "REQUESTS" {
+			label += " " + strings.ToLower(b.TokenType)
+		}
+
+		w := RateWindow{
+			Label:   label,
+			UsedPct: (1 - *b.RemainingFraction) * 100,
+		}
+		if t, err := time.Parse(time.RFC3339, b.ResetTime); err == nil {
+			w.ResetAt = t
+			w.HasReset = true
+		}
+		windows = append(windows, w)
+	}
+	return windows
+}
+
+func loadGeminiCodeAssist(ctx context.Context, accessToken string) (geminiLoadResponse, int, error) {
+	var load geminiLoadResponse
+	body := map[string]any{
+		"metadata": map[string]string{
+			"ideType":    "IDE_UNSPECIFIED",
+			"platform":   "PLATFORM_UNSPECIFIED",
+			"pluginType": "GEMINI",
+		},
+	}
+	status, err := postGeminiJSON(ctx, endpointURL("gemini")+":loadCodeAssist", accessToken, body, &load)
+	return load, status, err
+}
+
+func postGeminiJSON(ctx context.Context, endpoint, accessToken string, body any, out any) (int, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return 0, err
+	}
+
+	return http.StatusOK, nil
+}
+
+func refreshGeminiAuth(ctx context.Context, creds *geminiCredentials) (err error) {
+	defer func() { logRefresh(ctx, err) }()
+
+	if creds.RefreshToken == "" {
+		return fmt.Errorf("no Gemini refresh token found")
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", creds.RefreshToken)
+	form.Set("client_id", geminiOAuthClientID)
+	form.Set("client_secret", geminiOAuthSecret)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpointURL("gemini_token"), strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("token refresh HTTP %d", resp.StatusCode)
+	}
+
+	var refreshed geminiRefreshResponse
+	if err := json.NewDecoder(resp.Body).Decode(&refreshed); err != nil {
+		return err
+	}
+
+	if refreshed.AccessToken == "" {
+		return fmt.Errorf("token refresh returned no access token")
+	}
+
+	creds.AccessToken = refreshed.AccessToken
+	if refreshed.RefreshToken != "" {
+		creds.RefreshToken = refreshed.RefreshToken
+	}
+	if refreshed.IDToken != "" {
+		creds.IDToken = refreshed.IDToken
+	}
+	if refreshed.ExpiresIn > 0 {
+		creds.ExpiryDate = time.Now().Add(time.Duration(refreshed.ExpiresIn) * time.Second).UnixMilli()
+	}
+
+	return nil
+}
+
+func geminiTokenExpired(creds *geminiCredentials) bool {
+	if creds.ExpiryDate <= 0 {
+		return false
+	}
+
+	return time.Now().UnixMilli() >= (creds.ExpiryDate - 30_000)
+}
+
+func isGeminiAuthStatus(status int) bool {
+	return status == http.StatusUnauthorized || status == http.StatusForbidden
+}
+
+// geminiEmail reads the email claim from the OpenID token without verifying
+// it; it is only used as a display label.
+func geminiEmail(idToken string) string {
+	var claims struct {
+		Email string `json:"email"`
+	}
+	if err := decodeJWTClaims(idToken, &claims); err != nil {
+		return ""
+	}
+	return claims.Email
+}
+
+func loadGeminiCredentials() (*geminiCredentials, error) {
+	if replaying() {
+		return &geminiCredentials{AccessToken: replayCredential, RefreshToken: replayCredential}, nil
+	}
+
+	path, err := geminiCredentialsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var creds geminiCredentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, err
+	}
+
+	if creds.AccessToken == "" && creds.RefreshToken == "" {
+		return nil, fmt.Errorf("no Gemini OAuth tokens found")
+	}
+
+	return &creds, nil
+}
+
+func saveGeminiCredentials(creds *geminiCredentials) error {
+	if replaying() {
+		return nil // keep the real tokens, not the tape's placeholders
+	}
+
+	path, err := geminiCredentialsPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	raw["access_token"] = creds.AccessToken
+	raw["refresh_token"] = creds.RefreshToken
+	if creds.IDToken != "" {
+		raw["id_token"] = creds.IDToken
+	}
+	if creds.ExpiryDate > 0 {
+		raw["expiry_date"] = creds.ExpiryDate
+	}
+
+	updated, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, updated, 0o600)
+}
+
+func geminiCredentialsPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".gemini", "oauth_creds.json"), nil
+}
@@ -0,0 +1,194 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeGeminiCreds(t *testing.T, body string) string {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	path := filepath.Join(home, ".gemini", "oauth_creds.json")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("write creds: %v", err)
+	}
+	return path
+}
+
+func TestGeminiConfiguredRequiresCredentialsFile(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if (Gemini{}).Configured() {
+		t.Fatal("expected Gemini to be unconfigured without credentials")
+	}
+
+	writeGeminiCreds(t, `{"access_token":"a"}`)
+	if !(Gemini{}).Configured() {
+		t.Fatal("expected Gemini to be configured with credentials")
+	}
+}
+
+func TestLoadGeminiCredentialsRequiresTokens(t *testing.T) {
+	writeGeminiCreds(t, `{}`)
+
+	_, err := loadGeminiCredentials()
+	if err == nil || !strings.Contains(err.Error(), "no Gemini OAuth tokens found") {
+		t.Fatalf("expected missing tokens error, got %v", err)
+	}
+}
+
+func TestGeminiEmailFromIDToken(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"email":"dev@example.com"}`))
+	if got := geminiEmail("header." + payload + ".sig"); got != "dev@example.com" {
+		t.Fatalf("unexpected email: %q", got)
+	}
+	if got := geminiEmail("not-a-jwt"); got != "" {
+		t.Fatalf("expected empty email for malformed token, got %q", got)
+	}
+}
+
+func TestGeminiFetchReportsQuotaWindows(t *testing.T) {
+	resetEndpoints(t)
+	writeGeminiCreds(t, `{"access_token":"token123","refresh_token":"r"}`)
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+
+	withMockDefaultClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("Authorization") != "Bearer token123" {
+			t.Fatalf("missing auth header: %q", req.Header.Get("Authorization"))
+		}
+
+		switch req.URL.String() {
+		case "https://cloudcode-pa.googleapis.com/v1internal:loadCodeAssist":
+			return jsonResponse(http.StatusOK, `{"currentTier":{"id":"free-tier","name":"Gemini Code Assist for individuals"},"cloudaicompanionProject":"proj-1"}`), nil
+		case "https://cloudcode-pa.googleapis.com/v1internal:retrieveUserQuota":
+			var body map[string]string
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				t.Fatalf("decode quota body: %v", err)
+			}
+			if body["project"] != "proj-1" {
+				t.Fatalf("expected project from loadCodeAssist, got %q", body["project"])
+			}
+			return jsonResponse(http.StatusOK, `{"buckets":[
+			  {"modelId":"gemini-2.5-pro","tokenType":"REQUESTS","remainingFraction":0.2,"resetTime":"2026-03-05T00:00:00Z"},
+			  {"modelId":"gemini-2.5-flash","tokenType":"REQUESTS","remainingFraction":0.9,"resetTime":"2026-03-05T00:00:00Z"}
+			]}`), nil
+		default:
+			t.Fatalf("unexpected URL: %s", req.URL.String())
+			return nil, nil
+		}
+	})
+
+	r := Gemini{}.Fetch(context.Background())
+
+	if r.Error != nil {
+		t.Fatalf("expected success, got error: %v", r.Error)
+	}
+	if r.Plan != "Gemini Code Assist for individuals" {
+		t.Fatalf("unexpected plan: %q", r.Plan)
+	}
+	if len(r.Windows) != 2 || r.Windows[0].Label != "gemini-2.5-flash" || !r.Windows[0].HasReset {
+		t.Fatalf("unexpected windows: %#v", r.Windows)
+	}
+	if r.Short != "80%" || r.Class != "warning" {
+		t.Fatalf("expected short/class from most-used window, got %q/%q", r.Short, r.Class)
+	}
+}
+
+func TestGeminiFetchWithoutQuotaBuckets(t *testing.T) {
+	writeGeminiCreds(t, `{"access_token":"token123","refresh_token":"r"}`)
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "proj-1")
+
+	withMockDefaultClient(t, func(req *http.Request) (*http.Response, error) {
+		if strings.HasSuffix(req.URL.Path, ":retrieveUserQuota") {
+			return jsonResponse(http.StatusOK, `{"buckets":[{"modelId":"gemini-2.5-pro","tokenType":"REQUESTS"}]}`), nil
+		}
+		return jsonResponse(http.StatusOK, `{"currentTier":{"id":"free-tier"},"cloudaicompanionProject":"proj-1"}`), nil
+	})
+
+	r := Gemini{}.Fetch(context.Background())
+	if r.Error == nil || r.Short != "?" {
+		t.Fatalf("expected an error for missing quota buckets, got %q (%v)", r.Short, r.Error)
+	}
+}
+
+func TestGeminiFetchRefreshesOnAuthFailure(t *testing.T) {
+	resetEndpoints(t)
+	path := writeGeminiCreds(t, `{"access_token":"old","refresh_token":"old-refresh","scope":"keep-me"}`)
+
+	loads := 0
+	withMockDefaultClient(t, func(req *http.Request) (*http.Response, error) {
+		switch req.URL.String() {
+		case "https://cloudcode-pa.googleapis.com/v1internal:loadCodeAssist":
+			loads++
+			if req.Header.Get("Authorization") == "Bearer old" {
+				return jsonResponse(http.StatusUnauthorized, `{}`), nil
+			}
+			return jsonResponse(http.StatusOK, `{"cloudaicompanionProject":"p"}`), nil
+		case geminiTokenURL:
+			body, _ := io.ReadAll(req.Body)
+			values, _ := url.ParseQuery(string(body))
+			if values.Get("refresh_token") != "old-refresh" || values.Get("client_id") != geminiOAuthClientID {
+				t.Fatalf("unexpected refresh form: %v", values)
+			}
+			return jsonResponse(http.StatusOK, `{"access_token":"new","expires_in":3600}`), nil
+		case "https://cloudcode-pa.googleapis.com/v1internal:retrieveUserQuota":
+			return jsonResponse(http.StatusOK, `{"buckets":[{"modelId":"gemini-2.5-pro","remainingFraction":0.5}]}`), nil
+		default:
+			t.Fatalf("unexpected URL: %s", req.URL.String())
+			return nil, nil
+		}
+	})
+
+	r := Gemini{}.Fetch(context.Background())
+	if r.Error != nil {
+		t.Fatalf("expected success after refresh, got %v", r.Error)
+	}
+	if loads != 2 {
+		t.Fatalf("expected retry after refresh, got %d loads", loads)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read creds: %v", err)
+	}
+	var saved map[string]any
+	if err := json.Unmarshal(raw, &saved); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if saved["access_token"] != "new" || saved["scope"] != "keep-me" {
+		t.Fatalf("expected refreshed token saved with unknown fields kept, got %#v", saved)
+	}
+	if exp, _ := saved["expiry_date"].(float64); int64(exp) <= time.Now().UnixMilli() {
+		t.Fatalf("expected future expiry_date, got %#v", saved["expiry_date"])
+	}
+}
+
+func TestGeminiFetchAuthFailureWithoutRefreshToken(t *testing.T) {
+	writeGeminiCreds(t, `{"access_token":"old"}`)
+
+	withMockDefaultClient(t, func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusUnauthorized, `{}`), nil
+	})
+
+	r := Gemini{}.Fetch(context.Background())
+	if r.Short != "!" {
+		t.Fatalf("expected short '!', got %q", r.Short)
+	}
+	if r.Error == nil || !strings.Contains(r.Error.Error(), "gemini auth expired") {
+		t.Fatalf("expected auth expired error, got %v", r.Error)
+	}
+}
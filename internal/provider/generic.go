@@ -0,0 +1,304 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jhartzell/ai-usage-bar/internal/config"
+)
+
+// Generic is a provider declared entirely in the config file: one HTTP
+// request whose JSON response is mapped onto a Result with JSONPath-style
+// expressions such as "$.usage.daily[0].percent".
+type Generic struct {
+	Config config.Provider
+}
+
+func (g Generic) Name() string { return g.Config.Name }
+
+func (g Generic) Fetch(ctx context.Context) Result {
+	r := Result{Name: g.Config.Name, Currency: g.Config.Currency}
+
+	doc, status, err := g.request(ctx)
+	if err != nil {
+		r.Error = err
+		r.Short = "?"
+		return r
+	}
+	if status == http.StatusUnauthorized || status == http.StatusForbidden {
+		r.Error = fmt.Errorf("%s credentials rejected (HTTP %d)", g.Config.Name, status)
+		r.Short = "!"
+		return r
+	}
+	if status != http.StatusOK {
+		r.Error = fmt.Errorf("HTTP %d", status)
+		r.Short = "?"
+		return r
+	}
+
+	r.Identity = jsonPathString(doc, g.Config.Identity)
+	r.Plan = jsonPathString(doc, g.Config.Plan)
+
+	for _, m := range g.Config.Windows {
+		if w, ok := genericWindow(doc, m); ok {
+			r.Windows = append(r.Windows, w)
+		}
+	}
+	for _, m := range g.Config.Spend {
+		if v, ok := jsonPathNumber(doc, m.Amount); ok {
+			r.Spend = append(r.Spend, SpendEntry{Label: m.Label, Amount: v})
+		}
+	}
+	if v, ok := jsonPathNumber(doc, g.Config.Credits); ok {
+		r.Credits = &v
+	}
+
+	if !summarize(&r) {
+		r.Error = fmt.Errorf("no configured mapping matched the response")
+		r.Short = "?"
+	}
+
+	return r
+}
+
+// summarize fills in Short and Class from the worst window, else the
+// credits, else the first spend entry. It reports
+// false when the result has nothing to summarize.
+func summarize(r *Result) bool {
+	r.Class = "normal"
+	symbol := CurrencySymbol(r.Currency)
+	switch {
+	case len(r.Windows) > 0:
+		worst := r.Windows[0].UsedPct
+		for _, w := range r.Windows[1:] {
+			if w.UsedPct > worst {
+				worst = w.UsedPct
+			}
+		}
+		r.Short = fmt.Sprintf("%.0f%%", worst)
+		r.Class = classFromPct(worst)
+	case r.Credits != nil:
+		r.Short = fmt.Sprintf("%s%.2f", symbol, *r.Credits)
+	case len(r.Spend) > 0:
+		r.Short = fmt.Sprintf("%s%.2f", symbol, r.Spend[0].Amount)
+	default:
+		return false
+	}
+	return true
+}
+
+func (g Generic) request(ctx context.Context) (any, int, error) {
+	req, err := newConfigRequest(ctx, g.Config)
+	if err != nil {
+		return nil, 0, err
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, nil
+	}
+
+	data, err := readBody(resp)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, 0, fmt.Errorf("decode response: %w", err)
+	}
+	return doc, http.StatusOK, nil
+}
+
+// newConfigRequest builds the request declared by a config provider's URL,
+// Method, Body and Headers, expanding secret references. The method
+// defaults to GET.
+func newConfigRequest(ctx context.Context, pc config.Provider) (*http.Request, error) {
+	endpoint, err := config.Expand(pc.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	method := strings.ToUpper(pc.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	body, err := config.Expand(pc.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range pc.Headers {
+		v, err := config.Expand(value)
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %w", name, err)
+		}
+		req.Header.Set(name, v)
+	}
+	return req, nil
+}
+
+func genericWindow(doc any, m config.WindowMapping) (RateWindow, bool) {
+	w := RateWindow{Label: m.Label}
+
+	if pct, ok := jsonPathNumber(doc, m.Percent); ok {
+		w.UsedPct = pct
+	} else {
+		used, okUsed := jsonPathNumber(doc, m.Used)
+		limit, okLimit := jsonPathNumber(doc, m.Limit)
+		if !okUsed || !okLimit || limit <= 0 {
+			return w, false
+		}
+		w.UsedPct = used / limit * 100
+	}
+
+	if v, ok := jsonPath(doc, m.Reset); ok {
+		w.ResetAt, w.HasReset = parseResetValue(v)
+	}
+	return w, true
+}
+
+// parseResetValue accepts an RFC 3339 string or a unix timestamp in seconds
+// or milliseconds.
+func parseResetValue(v any) (time.Time, bool) {
+	if s, ok := v.(string); ok {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t, true
+		}
+	}
+	n, ok := toNumber(v)
+	if !ok || n <= 0 {
+		return time.Time{}, false
+	}
+	if n > 1e12 {
+		return time.UnixMilli(int64(n)), true
+	}
+	return time.Unix(int64(n), 0), true
+}
+
+// jsonPath evaluates a small JSONPath subset against a decoded JSON value:
+// "$" followed by ".key", "[index]" or "['key']" steps. An empty path
+// matches nothing.
+func jsonPath(doc any, path string) (any, bool) {
+	path = strings.TrimSpace(path)
+	if path == "" || path[0] != '$' {
+		return nil, false
+	}
+
+	cur := doc
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key := rest[:end]
+			rest = rest[end:]
+
+			obj, ok := cur.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if cur, ok = obj[key]; !ok {
+				return nil, false
+			}
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, false
+			}
+			sel := rest[1:end]
+			rest = rest[end+1:]
+
+			if len(sel) >= 2 && (sel[0] == '\'' || sel[0] == '"') && sel[len(sel)-1] == sel[0] {
+				obj, ok := cur.(map[string]any)
+				if !ok {
+					return nil, false
+				}
+				if cur, ok = obj[sel[1:len(sel)-1]]; !ok {
+					return nil, false
+				}
+				continue
+			}
+
+			i, err := strconv.Atoi(sel)
+			arr, ok := cur.([]any)
+			if err != nil || !ok {
+				return nil, false
+			}
+			if i < 0 {
+				i += len(arr)
+			}
+			if i < 0 || i >= len(arr) {
+				return nil, false
+			}
+			cur = arr[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, cur != nil
+}
+
+func jsonPathNumber(doc any, path string) (float64, bool) {
+	v, ok := jsonPath(doc, path)
+	if !ok {
+		return 0, false
+	}
+	return toNumber(v)
+}
+
+func jsonPathString(doc any, path string) string {
+	v, ok := jsonPath(doc, path)
+	if !ok {
+		return ""
+	}
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return ""
+	}
+}
+
+// toNumber accepts JSON numbers and numeric strings, which some APIs use
+// for money amounts.
+func toNumber(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
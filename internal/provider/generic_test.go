@@ -0,0 +1,126 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jhartzell/ai-usage-bar/internal/config"
+)
+
+func TestJSONPath(t *testing.T) {
+	var doc any
+	if err := json.Unmarshal([]byte(`{"a":{"b":[{"c":1},{"c":2}],"dotted.key":"x"}}`), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want any
+		ok   bool
+	}{
+		{path: "$.a.b[0].c", want: 1.0, ok: true},
+		{path: "$.a.b[-1].c", want: 2.0, ok: true},
+		{path: "$.a['dotted.key']", want: "x", ok: true},
+		{path: "$.a.b[5].c", ok: false},
+		{path: "$.missing", ok: false},
+		{path: "a.b", ok: false},
+		{path: "", ok: false},
+	}
+	for _, tt := range tests {
+		got, ok := jsonPath(doc, tt.path)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Fatalf("jsonPath(%q) = %v, %v; want %v, %v", tt.path, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestGenericFetchMapsResponse(t *testing.T) {
+	t.Setenv("GW_TOKEN", "tok-123")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			t.Fatalf("expected POST, got %s", req.Method)
+		}
+		if req.Header.Get("Authorization") != "Bearer tok-123" {
+			t.Fatalf("secret reference not expanded: %q", req.Header.Get("Authorization"))
+		}
+		writeJSON(w, `{
+		  "user":{"email":"dev@example.com","tier":"team"},
+		  "limits":[{"used":30,"max":40,"resets":1767225600}],
+		  "daily_pct":"12.5",
+		  "spend":{"today":"1.25"},
+		  "balance":88
+		}`)
+	}))
+	defer srv.Close()
+
+	g := Generic{Config: config.Provider{
+		Type:     "generic",
+		Name:     "Gateway",
+		URL:      srv.URL + "/usage",
+		Method:   "post",
+		Headers:  map[string]string{"Authorization": "Bearer ${env:GW_TOKEN}"},
+		Identity: "$.user.email",
+		Plan:     "$.user.tier",
+		Credits:  "$.balance",
+		Currency: "EUR",
+		Windows: []config.WindowMapping{
+			{Label: "Daily", Percent: "$.daily_pct"},
+			{Label: "Monthly", Used: "$.limits[0].used", Limit: "$.limits[0].max", Reset: "$.limits[0].resets"},
+			{Label: "Unmapped", Percent: "$.nope"},
+		},
+		Spend: []config.SpendMapping{{Label: "Today", Amount: "$.spend.today"}},
+	}}
+
+	r := g.Fetch(context.Background())
+	if r.Error != nil {
+		t.Fatalf("unexpected error: %v", r.Error)
+	}
+	if r.Identity != "dev@example.com" || r.Plan != "team" {
+		t.Fatalf("unexpected identity/plan: %q %q", r.Identity, r.Plan)
+	}
+	if len(r.Windows) != 2 {
+		t.Fatalf("expected two mapped windows, got %+v", r.Windows)
+	}
+	if !r.Windows[1].HasReset || !r.Windows[1].ResetAt.Equal(time.Unix(1767225600, 0)) {
+		t.Fatalf("unexpected reset: %+v", r.Windows[1])
+	}
+	if r.Short != "75%" || r.Class != "warning" {
+		t.Fatalf("expected worst window 75%% warning, got %q %q", r.Short, r.Class)
+	}
+	if r.Credits == nil || *r.Credits != 88 || r.Currency != "EUR" {
+		t.Fatalf("unexpected credits: %v %q", r.Credits, r.Currency)
+	}
+	if len(r.Spend) != 1 || r.Spend[0].Amount != 1.25 {
+		t.Fatalf("unexpected spend: %+v", r.Spend)
+	}
+}
+
+func TestGenericFetchErrors(t *testing.T) {
+	status := http.StatusUnauthorized
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	g := Generic{Config: config.Provider{Name: "Gateway", URL: srv.URL, Credits: "$.balance"}}
+
+	if r := g.Fetch(context.Background()); r.Short != "!" || r.Error == nil {
+		t.Fatalf("expected auth failure, got %q %v", r.Short, r.Error)
+	}
+
+	status = http.StatusOK
+	if r := g.Fetch(context.Background()); r.Short != "?" || r.Error == nil {
+		t.Fatalf("expected unmatched mapping error, got %q %v", r.Short, r.Error)
+	}
+
+	g.Config.Headers = map[string]string{"Authorization": "${env:AI_USAGE_BAR_UNSET_VAR}"}
+	if r := g.Fetch(context.Background()); r.Short != "?" || r.Error == nil {
+		t.Fatalf("expected unresolved secret error, got %q %v", r.Short, r.Error)
+	}
+}
@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// LiteLLM reports spend and budgets tracked by a self-hosted LiteLLM proxy
+// for the configured virtual key and, when the key belongs to one, its team.
+// BaseURL overrides LITELLM_BASE_URL, e.g. to point at a test server.
+type LiteLLM struct {
+	BaseURL string
+}
+
+func (LiteLLM) Name() string { return "LiteLLM" }
+
+func (LiteLLM) Configured() bool {
+	return os.Getenv("LITELLM_BASE_URL") != "" && os.Getenv("LITELLM_API_KEY") != ""
+}
+
+type liteLLMKeyInfoResponse struct {
+	Info liteLLMKeyInfo `json:"info"`
+}
+
+type liteLLMKeyInfo struct {
+	KeyAlias      string             `json:"key_alias"`
+	KeyName       string             `json:"key_name"`
+	TeamID        string             `json:"team_id"`
+	Spend         float64            `json:"spend"`
+	MaxBudget     *float64           `json:"max_budget"`
+	BudgetResetAt string             `json:"budget_reset_at"`
+	ModelSpend    map[string]float64 `json:"model_spend"`
+}
+
+type liteLLMTeamInfoResponse struct {
+	TeamInfo struct {
+		TeamAlias     string             `json:"team_alias"`
+		Spend         float64            `json:"spend"`
+		MaxBudget     *float64           `json:"max_budget"`
+		BudgetResetAt string             `json:"budget_reset_at"`
+		ModelSpend    map[string]float64 `json:"model_spend"`
+	} `json:"team_info"`
+}
+
+const liteLLMTopModels = 5
+
+func (l LiteLLM) Fetch(ctx context.Context) Result {
+	r := Result{Name: l.Name()}
+
+	base := l.baseURL()
+	key := credential(os.Getenv("LITELLM_API_KEY"))
+	if base == "" || key == "" {
+		r.Error = fmt.Errorf("LITELLM_BASE_URL and LITELLM_API_KEY must be set")
+		r.Short = "?"
+		return r
+	}
+
+	var keyInfo liteLLMKeyInfoResponse
+	if err := l.get(ctx, key, "/key/info", nil, &keyInfo); err != nil {
+		r.Error = err
+		r.Short = adminShort(err)
+		return r
+	}
+	info := keyInfo.Info
+
+	r.Identity = info.KeyAlias
+	if r.Identity == "" {
+		r.Identity = info.KeyName
+	}
+
+	r.Spend = append(r.Spend, SpendEntry{Label: "Key spend", Amount: info.Spend})
+	if w, ok := liteLLMBudgetWindow("Key budget", info.Spend, info.MaxBudget, info.BudgetResetAt); ok {
+		r.Windows = append(r.Windows, w)
+	}
+	r.Breakdown = append(r.Breakdown, liteLLMModelBreakdown("Key spend by model", info.ModelSpend)...)
+
+	if info.TeamID != "" {
+		l.addTeam(ctx, key, info.TeamID, &r)
+	}
+
+	summarize(&r)
+	return r
+}
+
+// addTeam adds the team's budget and spend. It is best-effort: keys can
+// usually read their own info but not always their team's.
+func (l LiteLLM) addTeam(ctx context.Context, key, teamID string, r *Result) {
+	var team liteLLMTeamInfoResponse
+	q := url.Values{}
+	q.Set("team_id", teamID)
+	if err := l.get(ctx, key, "/team/info", q, &team); err != nil {
+		return
+	}
+	t := team.TeamInfo
+
+	label := "Team"
+	if t.TeamAlias != "" {
+		label = t.TeamAlias
+	}
+	if r.Plan == "" {
+		r.Plan = label
+	}
+
+	r.Spend = append(r.Spend, SpendEntry{Label: label + " spend", Amount: t.Spend})
+	if w, ok := liteLLMBudgetWindow(label+" budget", t.Spend, t.MaxBudget, t.BudgetResetAt); ok {
+		r.Windows = append(r.Windows, w)
+	}
+	r.Breakdown = append(r.Breakdown, liteLLMModelBreakdown(label+" spend by model", t.ModelSpend)...)
+}
+
+func (l LiteLLM) get(ctx context.Context, key, path string, q url.Values, out any) error {
+	endpoint := l.baseURL() + path
+	if len(q) > 0 {
+		endpoint += "?" + q.Encode()
+	}
+	return getJSON(ctx, endpoint, func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+key)
+		req.Header.Set("Accept", "application/json")
+	}, "litellm api key", out)
+}
+
+func (l LiteLLM) baseURL() string {
+	base := l.BaseURL
+	if base == "" {
+		base = os.Getenv("LITELLM_BASE_URL")
+	}
+	return strings.TrimRight(base, "/")
+}
+
+func liteLLMBudgetWindow(label string, spend float64, maxBudget *float64, resetAt string) (RateWindow, bool) {
+	if maxBudget == nil || *maxBudget <= 0 {
+		return RateWindow{}, false
+	}
+
+	w := RateWindow{Label: label, UsedPct: spend / *maxBudget * 100}
+	w.ResetAt, w.HasReset = parseLiteLLMTime(resetAt)
+	return w, true
+}
+
+// parseLiteLLMTime parses the proxy's timestamps, which may lack a zone
+// depending on the database backend; those are UTC.
+func parseLiteLLMTime(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse("2006-01-02T15:04:05.999999", s); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+func liteLLMModelBreakdown(group string, spend map[string]float64) []BreakdownEntry {
+	rows := map[string]map[string]*BreakdownEntry{}
+	for model, amount := range spend {
+		addBreakdown(rows, group, model, amount, 0)
+	}
+	return topBreakdown(rows[group], liteLLMTopModels)
+}
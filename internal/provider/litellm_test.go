@@ -0,0 +1,112 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLiteLLMFetchRequiresConfig(t *testing.T) {
+	t.Setenv("LITELLM_BASE_URL", "")
+	t.Setenv("LITELLM_API_KEY", "")
+
+	r := LiteLLM{}.Fetch(context.Background())
+	if r.Error == nil {
+		t.Fatal("expected missing config error")
+	}
+	if r.Short != "?" {
+		t.Fatalf("expected short '?', got %q", r.Short)
+	}
+}
+
+func TestLiteLLMFetchAuthFailure(t *testing.T) {
+	t.Setenv("LITELLM_API_KEY", "sk-bad")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	r := LiteLLM{BaseURL: srv.URL}.Fetch(context.Background())
+	if r.Error == nil {
+		t.Fatal("expected auth error")
+	}
+	if r.Short != "!" {
+		t.Fatalf("expected short '!', got %q", r.Short)
+	}
+}
+
+func TestLiteLLMFetchKeyAndTeamBudgets(t *testing.T) {
+	t.Setenv("LITELLM_API_KEY", "sk-team")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer sk-team" {
+			t.Fatalf("missing auth header: %q", req.Header.Get("Authorization"))
+		}
+
+		switch req.URL.Path {
+		case "/key/info":
+			writeJSON(w, `{"key":"hashed","info":{
+			  "key_alias":"ci-bot","team_id":"team-1","spend":12.5,"max_budget":50,
+			  "budget_reset_at":"2026-04-01T00:00:00+00:00",
+			  "model_spend":{"gpt-4o":10,"claude-sonnet":2.5}
+			}}`)
+		case "/team/info":
+			if got := req.URL.Query().Get("team_id"); got != "team-1" {
+				t.Fatalf("unexpected team_id %q", got)
+			}
+			writeJSON(w, `{"team_id":"team-1","team_info":{
+			  "team_alias":"ML","spend":90,"max_budget":100,
+			  "budget_reset_at":"2026-04-01T00:00:00.000000"
+			}}`)
+		default:
+			t.Fatalf("unexpected path %q", req.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	r := LiteLLM{BaseURL: srv.URL + "/"}.Fetch(context.Background())
+	if r.Error != nil {
+		t.Fatalf("unexpected error: %v", r.Error)
+	}
+	if r.Identity != "ci-bot" || r.Plan != "ML" {
+		t.Fatalf("unexpected identity/plan: %q %q", r.Identity, r.Plan)
+	}
+	if len(r.Windows) != 2 || r.Windows[0].UsedPct != 25 || r.Windows[1].UsedPct != 90 {
+		t.Fatalf("unexpected windows: %+v", r.Windows)
+	}
+	reset := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	for _, w := range r.Windows {
+		if !w.HasReset || !w.ResetAt.Equal(reset) {
+			t.Fatalf("unexpected reset for %s: %+v", w.Label, w)
+		}
+	}
+	if r.Short != "90%" || r.Class != "critical" {
+		t.Fatalf("expected team budget to drive 90%% critical, got %q %q", r.Short, r.Class)
+	}
+	if len(r.Spend) != 2 || r.Spend[0].Amount != 12.5 || r.Spend[1].Label != "ML spend" {
+		t.Fatalf("unexpected spend: %+v", r.Spend)
+	}
+	if len(r.Breakdown) != 2 || r.Breakdown[0].Label != "gpt-4o" || r.Breakdown[0].Group != "Key spend by model" {
+		t.Fatalf("unexpected breakdown: %+v", r.Breakdown)
+	}
+}
+
+func TestLiteLLMFetchWithoutBudget(t *testing.T) {
+	t.Setenv("LITELLM_API_KEY", "sk-solo")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, `{"info":{"key_name":"sk-...solo","spend":3.2,"max_budget":null}}`)
+	}))
+	defer srv.Close()
+
+	r := LiteLLM{BaseURL: srv.URL}.Fetch(context.Background())
+	if r.Error != nil {
+		t.Fatalf("unexpected error: %v", r.Error)
+	}
+	if len(r.Windows) != 0 || r.Short != "$3.20" || r.Class != "normal" {
+		t.Fatalf("expected spend-only result, got %+v", r)
+	}
+}
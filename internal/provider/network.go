@@ -0,0 +1,279 @@
+package provider
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/jhartzell/ai-usage-bar/internal/config"
+)
+
+// network holds the clients built from the config file's network section.
+// With no settings every provider uses http.DefaultClient.
+var network struct {
+	sync.RWMutex
+	base        *http.Client
+	perProvider map[string]*http.Client
+}
+
+type providerNameKey struct{}
+
+// withProviderName tags ctx with the provider a request is made for, so
+// per-provider network settings apply without threading them through
+// every fetch function.
+func withProviderName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, providerNameKey{}, name)
+}
+
+// providerName returns the provider ctx was tagged with, or "".
+func providerName(ctx context.Context) string {
+	name, _ := ctx.Value(providerNameKey{}).(string)
+	return name
+}
+
+// clientFor returns the HTTP client for the provider named in ctx,
+// recording or replaying through a tape when one is set.
+func clientFor(ctx context.Context) *http.Client {
+	return tapeClient(networkClient(ctx))
+}
+
+func networkClient(ctx context.Context) *http.Client {
+	network.RLock()
+	defer network.RUnlock()
+
+	if c := network.perProvider[providerName(ctx)]; c != nil {
+		return c
+	}
+	if network.base != nil {
+		return network.base
+	}
+	return http.DefaultClient
+}
+
+// ConfigureNetwork builds the clients for n. Default calls it with the
+// config file's settings; an error leaves the previous clients in place.
+func ConfigureNetwork(n config.Network) error {
+	if n.Proxy == "" && len(n.CAFiles) == 0 && n.ConnectTimeout == "" && n.ReadTimeout == "" && len(n.Providers) == 0 {
+		network.Lock()
+		network.base, network.perProvider = nil, nil
+		network.Unlock()
+		return nil
+	}
+
+	roots, err := loadCAFiles(n.CAFiles)
+	if err != nil {
+		return err
+	}
+	connect, err := optionalDuration(n.ConnectTimeout)
+	if err != nil {
+		return err
+	}
+	read, err := optionalDuration(n.ReadTimeout)
+	if err != nil {
+		return err
+	}
+
+	build := func(proxy string) (*http.Client, error) {
+		t, err := newTransport(proxy, roots, connect, read)
+		if err != nil {
+			return nil, err
+		}
+		return &http.Client{Transport: t}, nil
+	}
+
+	base, err := build(n.Proxy)
+	if err != nil {
+		return err
+	}
+	perProvider := map[string]*http.Client{}
+	for name, o := range n.Providers {
+		if o.Proxy == "" {
+			continue
+		}
+		c, err := build(o.Proxy)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		perProvider[name] = c
+	}
+
+	network.Lock()
+	network.base, network.perProvider = base, perProvider
+	network.Unlock()
+	return nil
+}
+
+func newTransport(proxy string, roots *x509.CertPool, connect, read time.Duration) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	switch proxy {
+	case "":
+		// keep ProxyFromEnvironment
+	case "direct":
+		t.Proxy = nil
+	default:
+		u, err := url.Parse(proxy)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("bad proxy URL %q", proxy)
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+
+	if roots != nil {
+		t.TLSClientConfig = &tls.Config{RootCAs: roots}
+	}
+	if connect > 0 {
+		t.DialContext = (&net.Dialer{Timeout: connect, KeepAlive: 30 * time.Second}).DialContext
+		t.TLSHandshakeTimeout = connect
+	}
+	if read > 0 {
+		t.ResponseHeaderTimeout = read
+	}
+	return t, nil
+}
+
+// loadCAFiles returns the system roots plus every certificate in files, or
+// nil when there are no files.
+func loadCAFiles(files []string) (*x509.CertPool, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(config.ExpandHome(f))
+		if err != nil {
+			return nil, fmt.Errorf("read CA file: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no PEM certificates in %s", f)
+		}
+	}
+	return pool, nil
+}
+
+func optionalDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("bad timeout %q", s)
+	}
+	return d, nil
+}
+
+// Endpoint returns a URL that p fetches from, for connectivity checks, or
+// "" when p makes no HTTP requests of its own.
+func Endpoint(p Provider) string {
+	switch p := p.(type) {
+	case Claude:
+		return endpointURL("claude") + "/usage"
+	case Codex:
+		return endpointURL("codex") + "/wham/usage"
+	case OpenRouter:
+		return endpointURL("openrouter") + "/key"
+	case AnthropicAdmin:
+		return p.baseURL()
+	case OpenAI:
+		return p.baseURL()
+	case Gemini:
+		return endpointURL("gemini") + ":retrieveUserQuota"
+	case Copilot:
+		return endpointURL("copilot") + "/copilot_internal/user"
+	case Cursor:
+		return p.baseURL()
+	case Balance:
+		return p.baseURL()
+	case LiteLLM:
+		return p.baseURL()
+	case Generic:
+		u, _ := config.Expand(p.Config.URL)
+		return u
+	case Probe:
+		pc, err := p.resolve()
+		if err != nil {
+			return ""
+		}
+		u, _ := config.Expand(pc.URL)
+		return u
+	}
+	return ""
+}
+
+// PingResult is the outcome of a connectivity check.
+type PingResult struct {
+	URL     string
+	Proxy   string // proxy the request went through, "" when direct
+	Status  int
+	Elapsed time.Duration
+	Err     error
+}
+
+// Ping sends one unauthenticated HEAD request to p's endpoint using p's
+// network settings. Any HTTP response, even an error status, means the
+// endpoint is reachable. For a plugin, which has no endpoint, it checks
+// that the command exists.
+func Ping(ctx context.Context, p Provider) PingResult {
+	res := PingResult{URL: Endpoint(p)}
+	if plugin, ok := p.(Plugin); ok {
+		if _, err := exec.LookPath(config.ExpandHome(plugin.Config.Command)); err != nil {
+			res.Err = err
+		}
+		return res
+	}
+	if res.URL == "" {
+		return res
+	}
+
+	ctx = withProviderName(ctx, p.Name())
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, res.URL, nil)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+
+	client := clientFor(ctx)
+	proxyFunc := http.ProxyFromEnvironment
+	if t, ok := client.Transport.(*http.Transport); ok {
+		proxyFunc = t.Proxy
+	}
+	if proxyFunc != nil {
+		if u, err := proxyFunc(req); err == nil && u != nil {
+			res.Proxy = u.Redacted()
+		}
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	res.Elapsed = time.Since(start)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	resp.Body.Close()
+	res.Status = resp.StatusCode
+	return res
+}
+
+// IsCertificateError reports whether err is a TLS verification failure,
+// the usual symptom of an intercepting proxy whose CA isn't trusted.
+func IsCertificateError(err error) bool {
+	var verify *tls.CertificateVerificationError
+	var unknown x509.UnknownAuthorityError
+	var invalid x509.CertificateInvalidError
+	var host x509.HostnameError
+	return errors.As(err, &verify) || errors.As(err, &unknown) || errors.As(err, &invalid) || errors.As(err, &host)
+}
@@ -0,0 +1,153 @@
+package provider
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jhartzell/ai-usage-bar/internal/config"
+)
+
+func resetNetwork(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() { _ = ConfigureNetwork(config.Network{}) })
+}
+
+func fakeProxy(t *testing.T, hits *[]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		*hits = append(*hits, req.Host)
+		writeJSON(w, `{}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestConfigureNetworkProxyPerProvider(t *testing.T) {
+	resetNetwork(t)
+
+	var global, override []string
+	globalProxy := fakeProxy(t, &global)
+	overrideProxy := fakeProxy(t, &override)
+
+	err := ConfigureNetwork(config.Network{
+		Proxy: globalProxy.URL,
+		Providers: map[string]config.NetworkOverride{
+			"Gateway": {Proxy: overrideProxy.URL},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, name := range []string{"Claude", "Gateway"} {
+		ctx := withProviderName(context.Background(), name)
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://usage.example.test/v1", nil)
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		resp.Body.Close()
+	}
+
+	if len(global) != 1 || global[0] != "usage.example.test" {
+		t.Fatalf("expected Claude through the global proxy, got %v", global)
+	}
+	if len(override) != 1 {
+		t.Fatalf("expected Gateway through its own proxy, got %v", override)
+	}
+}
+
+func TestConfigureNetworkTrustsExtraCAFiles(t *testing.T) {
+	resetNetwork(t)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, `{}`)
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // the untrusted handshake is expected
+	srv.StartTLS()
+	defer srv.Close()
+
+	get := func() error {
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, srv.URL, nil)
+		resp, err := clientFor(req.Context()).Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	if err := ConfigureNetwork(config.Network{ConnectTimeout: "2s"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(); !IsCertificateError(err) {
+		t.Fatalf("expected certificate error without the CA, got %v", err)
+	}
+
+	ca := filepath.Join(t.TempDir(), "corp.pem")
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(ca, pemData, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ConfigureNetwork(config.Network{CAFiles: []string{ca}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(); err != nil {
+		t.Fatalf("expected the extra CA to be trusted, got %v", err)
+	}
+
+	if err := ConfigureNetwork(config.Network{CAFiles: []string{filepath.Join(t.TempDir(), "missing.pem")}}); err == nil {
+		t.Fatal("expected missing CA file error")
+	}
+}
+
+func TestDefaultReportsNetworkErrors(t *testing.T) {
+	resetNetwork(t)
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	if err := os.MkdirAll(filepath.Join(dir, "ai-usage-bar"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := `{"network":{"ca_files":["/nonexistent/ca.pem"]}}`
+	if err := os.WriteFile(filepath.Join(dir, "ai-usage-bar", "config.json"), []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	providers := Default()
+	last := providers[len(providers)-1]
+	if last.Name() != "Network" {
+		t.Fatalf("expected a Network error provider, got %s", last.Name())
+	}
+	if r := last.Fetch(context.Background()); r.Error == nil || r.Short != "?" {
+		t.Fatalf("unexpected result: %+v", r)
+	}
+}
+
+func TestPing(t *testing.T) {
+	resetNetwork(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodHead {
+			t.Fatalf("expected HEAD, got %s", req.Method)
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	res := Ping(context.Background(), Generic{Config: config.Provider{Name: "Gateway", URL: srv.URL + "/usage"}})
+	if res.Err != nil || res.Status != http.StatusUnauthorized || res.URL != srv.URL+"/usage" {
+		t.Fatalf("unexpected ping result: %+v", res)
+	}
+
+	res = Ping(context.Background(), Plugin{Config: config.Provider{Name: "P", Command: "/nonexistent/plugin"}})
+	if res.Err == nil {
+		t.Fatal("expected missing plugin command error")
+	}
+}
@@ -0,0 +1,289 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenAI reports OpenAI platform (API-key billing) organization costs using
+// an admin key. BaseURL overrides the API host; when empty
+// OPENAI_ADMIN_BASE_URL or the public API is used.
+type OpenAI struct {
+	BaseURL string
+}
+
+func (OpenAI) Name() string { return "OpenAI" }
+
+func (OpenAI) Configured() bool { return os.Getenv("OPENAI_ADMIN_KEY") != "" }
+
+type openAIPage struct {
+	Data     []openAIBucket `json:"data"`
+	HasMore  bool           `json:"has_more"`
+	NextPage *string        `json:"next_page"`
+}
+
+type openAIBucket struct {
+	StartTime int64          `json:"start_time"`
+	Results   []openAIResult `json:"results"`
+}
+
+type openAIResult struct {
+	Amount *struct {
+		Value    float64 `json:"value"`
+		Currency string  `json:"currency"`
+	} `json:"amount"`
+	LineItem  *string `json:"line_item"`
+	ProjectID *string `json:"project_id"`
+
+	Model            *string `json:"model"`
+	InputTokens      int64   `json:"input_tokens"`
+	OutputTokens     int64   `json:"output_tokens"`
+	NumModelRequests int     `json:"num_model_requests"`
+}
+
+type openAIProjectsResponse struct {
+	Data []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"data"`
+	HasMore bool   `json:"has_more"`
+	LastID  string `json:"last_id"`
+}
+
+const (
+	openAIAdminBaseURL    = "https://api.openai.com"
+	openAIDefaultProject  = "Default project"
+	openAITopRows         = 5
+	openAIDailyRows       = 7
+	openAIReportPageLimit = "31"
+)
+
+func (o OpenAI) Fetch(ctx context.Context) Result {
+	return o.fetch(ctx, time.Now())
+}
+
+func (o OpenAI) fetch(ctx context.Context, now time.Time) Result {
+	r := Result{Name: o.Name()}
+
+	key := credential(os.Getenv("OPENAI_ADMIN_KEY"))
+	if key == "" {
+		r.Error = fmt.Errorf("OPENAI_ADMIN_KEY not set")
+		r.Short = "?"
+		return r
+	}
+
+	p := newSpendPeriods(now)
+
+	q := url.Values{}
+	q.Set("start_time", strconv.FormatInt(p.start().Unix(), 10))
+	q.Set("bucket_width", "1d")
+	q.Set("limit", openAIReportPageLimit)
+	q.Add("group_by", "project_id")
+	q.Add("group_by", "line_item")
+
+	var costs []openAIBucket
+	if err := o.getPages(ctx, key, "/v1/organization/costs", q, &costs); err != nil {
+		r.Error = err
+		r.Short = adminShort(err)
+		return r
+	}
+
+	projects := o.projectNames(ctx, key)
+	totals := map[string]float64{}
+	daily := map[int64]float64{}
+	byProject := map[string]map[string]*BreakdownEntry{}
+	byModel := map[string]map[string]*BreakdownEntry{}
+
+	for _, bucket := range costs {
+		t := time.Unix(bucket.StartTime, 0)
+		if _, ok := daily[bucket.StartTime]; !ok {
+			daily[bucket.StartTime] = 0 // days without cost still get a row
+		}
+		for _, c := range bucket.Results {
+			if c.Amount == nil {
+				continue
+			}
+			amount := c.Amount.Value
+			daily[bucket.StartTime] += amount
+			project := openAIProjectLabel(c.ProjectID, projects)
+			model := openAILineItemModel(c.LineItem)
+
+			for _, period := range p.containing(t) {
+				totals[period] += amount
+				addBreakdown(byProject, period+" by project", project, amount, 0)
+				addBreakdown(byModel, period+" by model", model, amount, 0)
+			}
+		}
+	}
+
+	o.addModelUsage(ctx, key, p, projects, byProject, byModel)
+
+	for _, period := range spendPeriodLabels {
+		r.Spend = append(r.Spend, SpendEntry{Label: period, Amount: totals[period]})
+	}
+	r.Breakdown = append(r.Breakdown, openAIDailyBreakdown(daily)...)
+	for _, period := range spendPeriodLabels {
+		r.Breakdown = append(r.Breakdown, topBreakdown(byProject[period+" by project"], openAITopRows)...)
+		r.Breakdown = append(r.Breakdown, topBreakdown(byModel[period+" by model"], openAITopRows)...)
+	}
+
+	month := totals["This month"]
+	r.Short = fmt.Sprintf("$%.2f", month)
+	r.Class = "normal"
+
+	if budget := openAIMonthlyBudget(); budget > 0 {
+		usedPct := month / budget * 100
+		r.Windows = append(r.Windows, RateWindow{
+			Label:    "Budget",
+			UsedPct:  usedPct,
+			ResetAt:  p.monthStart.AddDate(0, 1, 0),
+			HasReset: true,
+		})
+		r.Class = classFromPct(usedPct)
+	}
+
+	return r
+}
+
+// openAIDailyBreakdown lists the cost of each reported day, newest first,
+// up to openAIDailyRows days.
+func openAIDailyBreakdown(daily map[int64]float64) []BreakdownEntry {
+	days := make([]int64, 0, len(daily))
+	for d := range daily {
+		days = append(days, d)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i] > days[j] })
+	if len(days) > openAIDailyRows {
+		days = days[:openAIDailyRows]
+	}
+
+	entries := make([]BreakdownEntry, 0, len(days))
+	for _, d := range days {
+		entries = append(entries, BreakdownEntry{
+			Group:  "Daily cost",
+			Label:  time.Unix(d, 0).UTC().Format("Mon Jan 2"),
+			Amount: daily[d],
+		})
+	}
+	return entries
+}
+
+// addModelUsage merges this month's request and token counts from the
+// completions usage report into the model and project rows. It is
+// best-effort: cost data alone is still useful.
+func (o OpenAI) addModelUsage(ctx context.Context, key string, p spendPeriods, projects map[string]string, byProject, byModel map[string]map[string]*BreakdownEntry) {
+	q := url.Values{}
+	q.Set("start_time", strconv.FormatInt(p.monthStart.Unix(), 10))
+	q.Set("bucket_width", "1d")
+	q.Set("limit", openAIReportPageLimit)
+	q.Add("group_by", "model")
+	q.Add("group_by", "project_id")
+
+	var usage []openAIBucket
+	if err := o.getPages(ctx, key, "/v1/organization/usage/completions", q, &usage); err != nil {
+		return
+	}
+
+	for _, bucket := range usage {
+		for _, u := range bucket.Results {
+			if u.Model == nil || *u.Model == "" {
+				continue
+			}
+			tokens := u.InputTokens + u.OutputTokens
+			addBreakdown(byModel, "This month by model", *u.Model, 0, tokens)
+			byModel["This month by model"][*u.Model].Requests += u.NumModelRequests
+
+			project := openAIProjectLabel(u.ProjectID, projects)
+			addBreakdown(byProject, "This month by project", project, 0, tokens)
+			byProject["This month by project"][project].Requests += u.NumModelRequests
+		}
+	}
+}
+
+func (o OpenAI) projectNames(ctx context.Context, key string) map[string]string {
+	names := map[string]string{}
+	q := url.Values{}
+	q.Set("limit", "100")
+
+	_ = getPages(ctx, o.baseURL()+"/v1/organization/projects", q, "after", o.auth(key), "openai admin key", func(body []byte) (string, error) {
+		var page openAIProjectsResponse
+		if err := json.Unmarshal(body, &page); err != nil {
+			return "", err
+		}
+		for _, p := range page.Data {
+			names[p.ID] = p.Name
+		}
+		if !page.HasMore {
+			return "", nil
+		}
+		return page.LastID, nil
+	})
+	return names
+}
+
+func (o OpenAI) getPages(ctx context.Context, key, path string, q url.Values, out *[]openAIBucket) error {
+	return getPages(ctx, o.baseURL()+path, q, "page", o.auth(key), "openai admin key", func(body []byte) (string, error) {
+		var page openAIPage
+		if err := json.Unmarshal(body, &page); err != nil {
+			return "", err
+		}
+		*out = append(*out, page.Data...)
+		if !page.HasMore || page.NextPage == nil {
+			return "", nil
+		}
+		return *page.NextPage, nil
+	})
+}
+
+func (o OpenAI) auth(key string) func(*http.Request) {
+	return func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+}
+
+func (o OpenAI) baseURL() string {
+	base := o.BaseURL
+	if base == "" {
+		base = os.Getenv("OPENAI_ADMIN_BASE_URL")
+	}
+	if base == "" {
+		base = endpointURL("openai_admin")
+	}
+	return strings.TrimRight(base, "/")
+}
+
+func openAIMonthlyBudget() float64 {
+	v, err := strconv.ParseFloat(os.Getenv("OPENAI_MONTHLY_BUDGET"), 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+func openAIProjectLabel(id *string, names map[string]string) string {
+	if id == nil || *id == "" {
+		return openAIDefaultProject
+	}
+	if name, ok := names[*id]; ok && name != "" {
+		return name
+	}
+	return *id
+}
+
+// openAILineItemModel extracts the model from a cost line item such as
+// "gpt-4o-2024-08-06, input".
+func openAILineItemModel(lineItem *string) string {
+	if lineItem == nil || *lineItem == "" {
+		return "other"
+	}
+	model, _, _ := strings.Cut(*lineItem, ",")
+	return strings.TrimSpace(model)
+}
@@ -0,0 +1,154 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOpenAIFetchRequiresKey(t *testing.T) {
+	t.Setenv("OPENAI_ADMIN_KEY", "")
+
+	r := OpenAI{}.Fetch(context.Background())
+	if r.Error == nil {
+		t.Fatal("expected missing key error")
+	}
+	if r.Short != "?" {
+		t.Fatalf("expected short '?', got %q", r.Short)
+	}
+}
+
+func TestOpenAIFetchAuthFailure(t *testing.T) {
+	t.Setenv("OPENAI_ADMIN_KEY", "sk-admin-test")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	r := OpenAI{BaseURL: srv.URL}.Fetch(context.Background())
+	if r.Error == nil {
+		t.Fatal("expected auth error")
+	}
+	if r.Short != "!" {
+		t.Fatalf("expected short '!', got %q", r.Short)
+	}
+}
+
+func TestOpenAIFetchCostsWithBudget(t *testing.T) {
+	t.Setenv("OPENAI_ADMIN_KEY", "sk-admin-test")
+	t.Setenv("OPENAI_MONTHLY_BUDGET", "20")
+
+	now := time.Date(2026, 3, 4, 15, 0, 0, 0, time.UTC)
+	day := func(d int) int64 { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC).Unix() }
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer sk-admin-test" {
+			t.Fatalf("missing auth header: %q", req.Header.Get("Authorization"))
+		}
+
+		switch req.URL.Path {
+		case "/v1/organization/costs":
+			if got := req.URL.Query()["group_by"]; len(got) != 2 {
+				t.Fatalf("expected project and line item grouping, got %v", got)
+			}
+			if req.URL.Query().Get("page") == "" {
+				writeJSON(w, `{"data":[
+				  {"start_time":`+itoa64(day(1))+`,"results":[
+				    {"amount":{"value":10,"currency":"usd"},"line_item":"gpt-5, input","project_id":null}
+				  ]}
+				],"has_more":true,"next_page":"cursor-2"}`)
+				return
+			}
+			writeJSON(w, `{"data":[
+			  {"start_time":`+itoa64(day(4))+`,"results":[
+			    {"amount":{"value":4,"currency":"usd"},"line_item":"gpt-5, output","project_id":"proj_1"},
+			    {"amount":{"value":1,"currency":"usd"},"line_item":"o3, input","project_id":"proj_2"},
+			    {"amount":{"value":0,"currency":"usd"},"line_item":"o3, input","project_id":"proj_3"}
+			  ]}
+			],"has_more":false,"next_page":null}`)
+		case "/v1/organization/projects":
+			if req.URL.Query().Get("after") == "" {
+				writeJSON(w, `{"data":[{"id":"proj_1","name":"Agents"}],"has_more":true,"last_id":"proj_1"}`)
+				return
+			}
+			writeJSON(w, `{"data":[{"id":"proj_3","name":"Batch"}],"has_more":false,"last_id":"proj_3"}`)
+		case "/v1/organization/usage/completions":
+			writeJSON(w, `{"data":[{"start_time":`+itoa64(day(4))+`,"results":[
+			  {"model":"gpt-5","project_id":"proj_1","input_tokens":1000,"output_tokens":500,"num_model_requests":7}
+			]}],"has_more":false,"next_page":null}`)
+		default:
+			t.Fatalf("unexpected path: %s", req.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	r := OpenAI{BaseURL: srv.URL}.fetch(context.Background(), now)
+
+	if r.Error != nil {
+		t.Fatalf("expected success, got error: %v", r.Error)
+	}
+	if r.Short != "$15.00" {
+		t.Fatalf("unexpected short value: %q", r.Short)
+	}
+	if len(r.Spend) != 3 || r.Spend[0].Amount != 5 || r.Spend[1].Amount != 5 || r.Spend[2].Amount != 15 {
+		t.Fatalf("unexpected spend rows: %#v", r.Spend)
+	}
+	if len(r.Windows) != 1 || r.Windows[0].UsedPct != 75 || !r.Windows[0].HasReset {
+		t.Fatalf("unexpected budget window: %#v", r.Windows)
+	}
+	if !r.Windows[0].ResetAt.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected budget reset at next month, got %s", r.Windows[0].ResetAt)
+	}
+	if r.Class != "warning" {
+		t.Fatalf("expected warning class at 75%% of budget, got %q", r.Class)
+	}
+
+	var daily []BreakdownEntry
+	rows := map[string]BreakdownEntry{}
+	for _, b := range r.Breakdown {
+		rows[b.Group+"/"+b.Label] = b
+		if b.Group == "Daily cost" {
+			daily = append(daily, b)
+		}
+	}
+	if len(daily) != 2 || daily[0].Label != "Wed Mar 4" || daily[0].Amount != 5 || daily[1].Label != "Sun Mar 1" || daily[1].Amount != 10 {
+		t.Fatalf("unexpected daily rows: %#v", daily)
+	}
+	if b := rows["This month by project/Default project"]; b.Amount != 10 {
+		t.Fatalf("expected default project row, got %#v", r.Breakdown)
+	}
+	if _, ok := rows["Today by project/Batch"]; !ok {
+		t.Fatalf("expected project names from every page, got %#v", r.Breakdown)
+	}
+	if b := rows["Today by project/proj_2"]; b.Amount != 1 {
+		t.Fatalf("expected unnamed project to fall back to id, got %#v", r.Breakdown)
+	}
+	if b := rows["This month by model/gpt-5"]; b.Amount != 14 || b.Tokens != 1500 || b.Requests != 7 {
+		t.Fatalf("unexpected gpt-5 month row: %#v", b)
+	}
+	if b := rows["This month by project/Agents"]; b.Requests != 7 {
+		t.Fatalf("expected usage merged into project row, got %#v", b)
+	}
+}
+
+func TestOpenAILineItemModel(t *testing.T) {
+	item := "gpt-4o-2024-08-06, input"
+	if got := openAILineItemModel(&item); got != "gpt-4o-2024-08-06" {
+		t.Fatalf("unexpected model: %q", got)
+	}
+	if got := openAILineItemModel(nil); got != "other" {
+		t.Fatalf("expected nil line item to map to other, got %q", got)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(body))
+}
+
+func itoa64(n int64) string {
+	return itoa(int(n))
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type OpenRouter struct{}
@@ -26,48 +28,71 @@ type openRouterKeyResponse struct {
 	} `json:"data"`
 }
 
-func (o OpenRouter) Fetch(ctx context.Context) Result {
-	r := Result{Name: "OpenRouter"}
+type openRouterKeysResponse struct {
+	Data []openRouterKeyInfo `json:"data"`
+}
 
-	apiKey := os.Getenv("OPENROUTER_API_KEY")
-	if apiKey == "" {
-		r.Error = fmt.Errorf("OPENROUTER_API_KEY not set")
-		r.Short = "?"
-		return r
-	}
+type openRouterKeyInfo struct {
+	Name           string   `json:"name"`
+	Label          string   `json:"label"`
+	Disabled       bool     `json:"disabled"`
+	Limit          *float64 `json:"limit"`
+	LimitRemaining *float64 `json:"limit_remaining"`
+	Usage          float64  `json:"usage"`
+	UsageDaily     float64  `json:"usage_daily"`
+	UsageWeekly    float64  `json:"usage_weekly"`
+	UsageMonthly   float64  `json:"usage_monthly"`
+}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", "https://openrouter.ai/api/v1/key", nil)
-	if err != nil {
-		r.Error = err
-		r.Short = "?"
-		return r
-	}
-	req.Header.Set("Authorization", "Bearer "+apiKey)
+type openRouterCreditsResponse struct {
+	Data struct {
+		TotalCredits float64 `json:"total_credits"`
+		TotalUsage   float64 `json:"total_usage"`
+	} `json:"data"`
+}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		r.Error = err
-		r.Short = "?"
-		return r
-	}
-	defer resp.Body.Close()
+type openRouterActivityResponse struct {
+	Data []openRouterActivity `json:"data"`
+}
 
-	if resp.StatusCode == 401 || resp.StatusCode == 403 {
-		r.Error = fmt.Errorf("auth failed (HTTP %d)", resp.StatusCode)
-		r.Short = "!"
-		return r
+type openRouterActivity struct {
+	Date     string  `json:"date"`
+	Model    string  `json:"model"`
+	Usage    float64 `json:"usage"`
+	Requests int     `json:"requests"`
+}
+
+const (
+	openRouterBaseURL = "https://openrouter.ai/api/v1"
+
+	// openRouterTopModels caps each model breakdown table.
+	openRouterTopModels = 5
+	// openRouterActivityDays is the span of the longer model breakdown.
+	openRouterActivityDays = 30
+
+	// openRouterDefaultLowBalance is the account balance (in dollars) below
+	// which the card turns to warning, unless OPENROUTER_LOW_BALANCE is set.
+	openRouterDefaultLowBalance = 5.0
+)
+
+func (o OpenRouter) Fetch(ctx context.Context) Result {
+	if key := os.Getenv("OPENROUTER_PROVISIONING_KEY"); key != "" {
+		return o.fetchAccountKeys(ctx, credential(key))
 	}
 
-	if resp.StatusCode != 200 {
-		r.Error = fmt.Errorf("HTTP %d", resp.StatusCode)
+	r := Result{Name: "OpenRouter"}
+
+	apiKey := credential(os.Getenv("OPENROUTER_API_KEY"))
+	if apiKey == "" {
+		r.Error = fmt.Errorf("OPENROUTER_API_KEY not set")
 		r.Short = "?"
 		return r
 	}
 
 	var keyResp openRouterKeyResponse
-	if err := json.NewDecoder(resp.Body).Decode(&keyResp); err != nil {
+	if err := getOpenRouterJSON(ctx, endpointURL("openrouter")+"/key", apiKey, &keyResp); err != nil {
 		r.Error = err
-		r.Short = "?"
+		r.Short = openRouterShort(err)
 		return r
 	}
 
@@ -103,5 +128,221 @@ func (o OpenRouter) Fetch(ctx context.Context) Result {
 		r.Plan = "free"
 	}
 
+	addOpenRouterCredits(ctx, &r, apiKey)
+
+	return r
+}
+
+// fetchAccountKeys lists every API key on the account using a provisioning
+// key and reports one KeyUsage per key plus account-wide spend totals.
+func (o OpenRouter) fetchAccountKeys(ctx context.Context, provisioningKey string) Result {
+	r := Result{Name: "OpenRouter"}
+
+	var keysResp openRouterKeysResponse
+	if err := getOpenRouterJSON(ctx, endpointURL("openrouter")+"/keys", provisioningKey, &keysResp); err != nil {
+		r.Error = err
+		r.Short = openRouterShort(err)
+		return r
+	}
+
+	var daily, weekly, monthly, total float64
+	r.Class = "normal"
+	for _, k := range keysResp.Data {
+		ku := KeyUsage{
+			Label:     k.Name,
+			Limit:     k.Limit,
+			Remaining: k.LimitRemaining,
+			Daily:     k.UsageDaily,
+			Weekly:    k.UsageWeekly,
+			Monthly:   k.UsageMonthly,
+			Disabled:  k.Disabled,
+			Class:     "normal",
+		}
+		if ku.Label == "" {
+			ku.Label = k.Label
+		}
+		if k.Limit != nil && *k.Limit > 0 && !k.Disabled {
+			used := k.Usage
+			if k.LimitRemaining != nil {
+				used = *k.Limit - *k.LimitRemaining
+			}
+			ku.Class = classFromPct((used / *k.Limit) * 100)
+			r.Class = worstClass(r.Class, ku.Class)
+		}
+		r.Keys = append(r.Keys, ku)
+
+		daily += k.UsageDaily
+		weekly += k.UsageWeekly
+		monthly += k.UsageMonthly
+		total += k.Usage
+	}
+
+	r.Identity = fmt.Sprintf("%d keys", len(keysResp.Data))
+	if len(keysResp.Data) == 1 {
+		r.Identity = "1 key"
+	}
+	r.Short = fmt.Sprintf("$%.2f", monthly)
+	r.Spend = []SpendEntry{
+		{Label: "Today", Amount: daily},
+		{Label: "This week", Amount: weekly},
+		{Label: "This month", Amount: monthly},
+		{Label: "All time", Amount: total},
+	}
+
+	addOpenRouterCredits(ctx, &r, provisioningKey)
+
+	if openRouterModelBreakdownEnabled() {
+		addOpenRouterModelBreakdown(ctx, &r, provisioningKey, time.Now())
+	}
+
 	return r
 }
+
+func openRouterModelBreakdownEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("OPENROUTER_MODEL_BREAKDOWN"))
+	return enabled
+}
+
+// addOpenRouterModelBreakdown pulls the account's per-model activity and
+// appends the top models by spend for the latest reported day and the last
+// 30 days. The activity endpoint only returns completed UTC days, so the
+// latest day is normally yesterday. Like the credits lookup it is
+// best-effort.
+func addOpenRouterModelBreakdown(ctx context.Context, r *Result, key string, now time.Time) {
+	var activity openRouterActivityResponse
+	if err := getOpenRouterJSON(ctx, endpointURL("openrouter")+"/activity", key, &activity); err != nil {
+		return
+	}
+
+	// Dates are YYYY-MM-DD, so they compare as strings.
+	today := now.UTC()
+	since := today.AddDate(0, 0, -openRouterActivityDays).Format(time.DateOnly)
+	var latest string
+	var recent []openRouterActivity
+	for _, a := range activity.Data {
+		date := openRouterActivityDate(a)
+		if date < since {
+			continue
+		}
+		recent = append(recent, a)
+		if date > latest {
+			latest = date
+		}
+	}
+
+	var latestRows []openRouterActivity
+	for _, a := range recent {
+		if openRouterActivityDate(a) == latest {
+			latestRows = append(latestRows, a)
+		}
+	}
+
+	group := "Top models yesterday"
+	if d, err := time.Parse(time.DateOnly, latest); err == nil && latest != today.AddDate(0, 0, -1).Format(time.DateOnly) {
+		group = "Top models on " + d.Format("Jan 2")
+	}
+	r.Breakdown = append(r.Breakdown, topOpenRouterModels(group, latestRows)...)
+	r.Breakdown = append(r.Breakdown, topOpenRouterModels("Top models (30d)", recent)...)
+}
+
+// openRouterActivityDate returns the YYYY-MM-DD day an activity row covers.
+func openRouterActivityDate(a openRouterActivity) string {
+	if len(a.Date) > len(time.DateOnly) {
+		return a.Date[:len(time.DateOnly)]
+	}
+	return a.Date
+}
+
+func topOpenRouterModels(group string, rows []openRouterActivity) []BreakdownEntry {
+	byModel := map[string]map[string]*BreakdownEntry{}
+	for _, a := range rows {
+		addBreakdown(byModel, group, a.Model, a.Usage, 0)
+		byModel[group][a.Model].Requests += a.Requests
+	}
+	return topBreakdown(byModel[group], openRouterTopModels)
+}
+
+// addOpenRouterCredits reports the account balance as r.Credits, with the
+// purchased and used credits (and any key limit it replaces) under the
+// Balance group, and raises r.Class when the balance is low. Failures are
+// ignored so a key without access to the credits endpoint still renders.
+func addOpenRouterCredits(ctx context.Context, r *Result, key string) {
+	var credits openRouterCreditsResponse
+	if err := getOpenRouterJSON(ctx, endpointURL("openrouter")+"/credits", key, &credits); err != nil {
+		return
+	}
+
+	balance := credits.Data.TotalCredits - credits.Data.TotalUsage
+	if r.Credits != nil {
+		r.Breakdown = append(r.Breakdown, BreakdownEntry{Group: balanceGroup, Label: "Key limit remaining", Amount: *r.Credits})
+	}
+	r.Credits = &balance
+	r.Breakdown = append(r.Breakdown,
+		BreakdownEntry{Group: balanceGroup, Label: "Credits purchased", Amount: credits.Data.TotalCredits},
+		BreakdownEntry{Group: balanceGroup, Label: "Credits used", Amount: credits.Data.TotalUsage},
+	)
+
+	class := balanceClass(balance, openRouterLowBalance())
+	if r.Class == "" {
+		r.Class = class
+	} else {
+		r.Class = worstClass(r.Class, class)
+	}
+}
+
+func openRouterLowBalance() float64 {
+	if v, err := strconv.ParseFloat(os.Getenv("OPENROUTER_LOW_BALANCE"), 64); err == nil && v >= 0 {
+		return v
+	}
+	return openRouterDefaultLowBalance
+}
+
+func balanceClass(balance, low float64) string {
+	switch {
+	case balance <= 0:
+		return "critical"
+	case balance < low:
+		return "warning"
+	default:
+		return "normal"
+	}
+}
+
+type openRouterAuthError struct {
+	status int
+}
+
+func (e openRouterAuthError) Error() string {
+	return fmt.Sprintf("auth failed (HTTP %d)", e.status)
+}
+
+func getOpenRouterJSON(ctx context.Context, url, key string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+key)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return openRouterAuthError{status: resp.StatusCode}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func openRouterShort(err error) string {
+	if _, ok := err.(openRouterAuthError); ok {
+		return "!"
+	}
+	return "?"
+}
@@ -3,11 +3,14 @@ package provider
 import (
 	"context"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestOpenRouterFetchRequiresAPIKey(t *testing.T) {
 	t.Setenv("OPENROUTER_API_KEY", "")
+	t.Setenv("OPENROUTER_PROVISIONING_KEY", "")
 
 	r := OpenRouter{}.Fetch(context.Background())
 	if r.Error == nil {
@@ -20,6 +23,7 @@ func TestOpenRouterFetchRequiresAPIKey(t *testing.T) {
 
 func TestOpenRouterFetchAuthFailure(t *testing.T) {
 	t.Setenv("OPENROUTER_API_KEY", "test-key")
+	t.Setenv("OPENROUTER_PROVISIONING_KEY", "")
 
 	withMockDefaultClient(t, func(req *http.Request) (*http.Response, error) {
 		if req.Header.Get("Authorization") != "Bearer test-key" {
@@ -38,9 +42,14 @@ func TestOpenRouterFetchAuthFailure(t *testing.T) {
 }
 
 func TestOpenRouterFetchSuccessAndLabelFiltering(t *testing.T) {
+	resetEndpoints(t)
 	t.Setenv("OPENROUTER_API_KEY", "test-key")
+	t.Setenv("OPENROUTER_PROVISIONING_KEY", "")
 
 	withMockDefaultClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.URL.String() == "https://openrouter.ai/api/v1/credits" {
+			return jsonResponse(http.StatusForbidden, `{}`), nil
+		}
 		body := `{
 		  "data": {
 		    "label": "sk-live-should-not-show",
@@ -80,3 +89,225 @@ func TestOpenRouterFetchSuccessAndLabelFiltering(t *testing.T) {
 		t.Fatalf("expected 4 spend rows, got %d", len(r.Spend))
 	}
 }
+
+func TestOpenRouterFetchProvisioningKeyListsAccountKeys(t *testing.T) {
+	resetEndpoints(t)
+	t.Setenv("OPENROUTER_API_KEY", "")
+	t.Setenv("OPENROUTER_PROVISIONING_KEY", "prov-key")
+
+	withMockDefaultClient(t, func(req *http.Request) (*http.Response, error) {
+		if req.URL.String() == "https://openrouter.ai/api/v1/credits" {
+			return jsonResponse(http.StatusNotFound, `{}`), nil
+		}
+		if req.URL.String() != "https://openrouter.ai/api/v1/keys" {
+			t.Fatalf("unexpected URL: %s", req.URL.String())
+		}
+		if req.Header.Get("Authorization") != "Bearer prov-key" {
+			t.Fatalf("missing auth header: %q", req.Header.Get("Authorization"))
+		}
+		body := `{
+		  "data": [
+		    {"name": "agents", "label": "sk-or-v1-abc...123", "limit": 10, "limit_remaining": 0.5, "usage": 9.5, "usage_daily": 1, "usage_weekly": 4, "usage_monthly": 9.5},
+		    {"name": "", "label": "sk-or-v1-def...456", "limit": null, "limit_remaining": null, "usage": 3, "usage_daily": 0.25, "usage_weekly": 1, "usage_monthly": 2}
+		  ]
+		}`
+		return jsonResponse(http.StatusOK, body), nil
+	})
+
+	r := OpenRouter{}.Fetch(context.Background())
+
+	if r.Error != nil {
+		t.Fatalf("expected success, got error: %v", r.Error)
+	}
+	if len(r.Keys) != 2 {
+		t.Fatalf("expected 2 keys, got %#v", r.Keys)
+	}
+	if r.Keys[0].Label != "agents" || r.Keys[0].Class != "critical" {
+		t.Fatalf("expected critical named key, got %#v", r.Keys[0])
+	}
+	if r.Keys[1].Label != "sk-or-v1-def...456" || r.Keys[1].Class != "normal" {
+		t.Fatalf("expected unlimited key to fall back to label, got %#v", r.Keys[1])
+	}
+	if r.Class != "critical" {
+		t.Fatalf("expected account class from worst key, got %q", r.Class)
+	}
+	if r.Short != "$11.50" {
+		t.Fatalf("unexpected short value: %q", r.Short)
+	}
+	if r.Identity != "2 keys" {
+		t.Fatalf("unexpected identity: %q", r.Identity)
+	}
+	if len(r.Spend) != 4 || r.Spend[0].Amount != 1.25 {
+		t.Fatalf("unexpected spend rows: %#v", r.Spend)
+	}
+}
+
+func TestOpenRouterFetchProvisioningKeyAuthFailure(t *testing.T) {
+	t.Setenv("OPENROUTER_PROVISIONING_KEY", "prov-key")
+
+	withMockDefaultClient(t, func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusForbidden, `{}`), nil
+	})
+
+	r := OpenRouter{}.Fetch(context.Background())
+	if r.Error == nil {
+		t.Fatal("expected auth error")
+	}
+	if r.Short != "!" {
+		t.Fatalf("expected short '!', got %q", r.Short)
+	}
+}
+
+func TestOpenRouterFetchAddsAccountCredits(t *testing.T) {
+	resetEndpoints(t)
+	t.Setenv("OPENROUTER_API_KEY", "test-key")
+	t.Setenv("OPENROUTER_PROVISIONING_KEY", "")
+	t.Setenv("OPENROUTER_LOW_BALANCE", "10")
+
+	withMockDefaultClient(t, func(req *http.Request) (*http.Response, error) {
+		switch req.URL.String() {
+		case "https://openrouter.ai/api/v1/key":
+			return jsonResponse(http.StatusOK, `{"data":{"label":"dev","limit":null,"limit_remaining":null,"usage":42,"usage_monthly":12}}`), nil
+		case "https://openrouter.ai/api/v1/credits":
+			if req.Header.Get("Authorization") != "Bearer test-key" {
+				t.Fatalf("missing auth header: %q", req.Header.Get("Authorization"))
+			}
+			return jsonResponse(http.StatusOK, `{"data":{"total_credits":50,"total_usage":42.5}}`), nil
+		default:
+			t.Fatalf("unexpected URL: %s", req.URL.String())
+			return nil, nil
+		}
+	})
+
+	r := OpenRouter{}.Fetch(context.Background())
+
+	if r.Error != nil {
+		t.Fatalf("expected success, got error: %v", r.Error)
+	}
+	if r.Short != "$12.00" {
+		t.Fatalf("unexpected short value: %q", r.Short)
+	}
+	if r.Class != "warning" {
+		t.Fatalf("expected warning class from balance below threshold, got %q", r.Class)
+	}
+
+	if r.Credits == nil || *r.Credits != 7.5 {
+		t.Fatalf("expected an account balance of 7.5, got %v", r.Credits)
+	}
+	for _, s := range r.Spend {
+		if strings.HasPrefix(s.Label, "Credits") {
+			t.Fatalf("credits reported as spend: %#v", r.Spend)
+		}
+	}
+	want := map[string]float64{"Credits purchased": 50, "Credits used": 42.5}
+	for _, b := range r.Breakdown {
+		if amount, ok := want[b.Label]; ok && b.Group == "Balance" {
+			if b.Amount != amount {
+				t.Fatalf("%s: got %v, want %v", b.Label, b.Amount, amount)
+			}
+			delete(want, b.Label)
+		}
+	}
+	if len(want) != 0 {
+		t.Fatalf("missing credit rows: %#v (breakdown %#v)", want, r.Breakdown)
+	}
+}
+
+func TestBalanceClass(t *testing.T) {
+	tests := []struct {
+		balance float64
+		want    string
+	}{
+		{balance: 20, want: "normal"},
+		{balance: 5, want: "normal"},
+		{balance: 4.99, want: "warning"},
+		{balance: 0, want: "critical"},
+		{balance: -1, want: "critical"},
+	}
+
+	for _, tt := range tests {
+		if got := balanceClass(tt.balance, 5); got != tt.want {
+			t.Fatalf("balanceClass(%v): got %q, want %q", tt.balance, got, tt.want)
+		}
+	}
+}
+
+func TestOpenRouterFetchModelBreakdown(t *testing.T) {
+	resetEndpoints(t)
+	t.Setenv("OPENROUTER_PROVISIONING_KEY", "prov-key")
+	t.Setenv("OPENROUTER_MODEL_BREAKDOWN", "1")
+
+	// Activity covers completed UTC days only, so the latest is yesterday.
+	day := func(ago int) string { return time.Now().UTC().AddDate(0, 0, -ago).Format(time.DateOnly) }
+	withMockDefaultClient(t, func(req *http.Request) (*http.Response, error) {
+		switch req.URL.String() {
+		case "https://openrouter.ai/api/v1/keys":
+			return jsonResponse(http.StatusOK, `{"data":[]}`), nil
+		case "https://openrouter.ai/api/v1/activity":
+			body := `{"data":[
+			  {"date":"` + day(1) + `","model":"openai/gpt-4.1","usage":0.5,"requests":3},
+			  {"date":"` + day(1) + `","model":"anthropic/claude-opus-4","usage":4,"requests":2},
+			  {"date":"` + day(5) + `","model":"openai/gpt-4.1","usage":6,"requests":40},
+			  {"date":"` + day(6) + `","model":"google/gemini-2.5-flash","usage":0.1,"requests":100},
+			  {"date":"` + day(45) + `","model":"meta/llama-4","usage":50,"requests":1}
+			]}`
+			return jsonResponse(http.StatusOK, body), nil
+		default:
+			return jsonResponse(http.StatusNotFound, `{}`), nil
+		}
+	})
+
+	r := OpenRouter{}.Fetch(context.Background())
+
+	if r.Error != nil {
+		t.Fatalf("expected success, got error: %v", r.Error)
+	}
+
+	var latestRows, monthRows []BreakdownEntry
+	for _, b := range r.Breakdown {
+		switch b.Group {
+		case "Top models yesterday":
+			latestRows = append(latestRows, b)
+		case "Top models (30d)":
+			monthRows = append(monthRows, b)
+		}
+	}
+
+	if len(latestRows) != 2 || latestRows[0].Label != "anthropic/claude-opus-4" {
+		t.Fatalf("unexpected latest-day breakdown: %#v", r.Breakdown)
+	}
+	if len(monthRows) != 3 || monthRows[0].Label != "openai/gpt-4.1" || monthRows[0].Amount != 6.5 || monthRows[0].Requests != 43 {
+		t.Fatalf("unexpected 30d breakdown: %#v", monthRows)
+	}
+}
+
+func TestOpenRouterModelBreakdownNamesOlderLatestDay(t *testing.T) {
+	now := time.Date(2026, 3, 4, 15, 0, 0, 0, time.UTC)
+	withMockDefaultClient(t, func(req *http.Request) (*http.Response, error) {
+		return jsonResponse(http.StatusOK, `{"data":[
+		  {"date":"2026-03-01","model":"openai/gpt-4.1","usage":1,"requests":1},
+		  {"date":"2026-02-28","model":"openai/gpt-4.1","usage":2,"requests":1}
+		]}`), nil
+	})
+
+	var r Result
+	addOpenRouterModelBreakdown(context.Background(), &r, "prov-key", now)
+	if len(r.Breakdown) != 2 || r.Breakdown[0].Group != "Top models on Mar 1" || r.Breakdown[0].Amount != 1 {
+		t.Fatalf("unexpected breakdown: %#v", r.Breakdown)
+	}
+}
+
+func TestTopOpenRouterModelsCapsRows(t *testing.T) {
+	var rows []openRouterActivity
+	for i := 0; i < 8; i++ {
+		rows = append(rows, openRouterActivity{Model: string(rune('a' + i)), Usage: float64(i)})
+	}
+
+	got := topOpenRouterModels("g", rows)
+	if len(got) != openRouterTopModels {
+		t.Fatalf("expected %d rows, got %d", openRouterTopModels, len(got))
+	}
+	if got[0].Label != "h" {
+		t.Fatalf("expected highest spend first, got %#v", got[0])
+	}
+}
@@ -0,0 +1,172 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/jhartzell/ai-usage-bar/internal/config"
+)
+
+// Plugin runs an external executable that speaks the plugin protocol: it
+// receives a pluginRequest as JSON on stdin and prints a pluginResponse as
+// JSON on stdout. The README documents the protocol for plugin authors.
+type Plugin struct {
+	Config config.Provider
+}
+
+// defaultPluginTimeout matches the per-provider budget FetchAll applies;
+// a shorter configured timeout wins.
+const defaultPluginTimeout = 5 * time.Second
+
+// pluginWaitDelay bounds how long a plugin's leftover children may hold
+// its output open after it exits or is killed, e.g. a script that
+// backgrounds a command.
+const pluginWaitDelay = time.Second
+
+type pluginRequest struct {
+	Version  int             `json:"version"`
+	Name     string          `json:"name"`
+	Settings json.RawMessage `json:"settings"`
+}
+
+type pluginResponse struct {
+	Identity string           `json:"identity"`
+	Plan     string           `json:"plan"`
+	Short    string           `json:"short"`
+	Class    string           `json:"class"`
+	Windows  []pluginWindow   `json:"windows"`
+	Spend    []SpendEntry     `json:"spend"`
+	Credits  *float64         `json:"credits"`
+	Currency string           `json:"currency"`
+	Error    *pluginErrorBody `json:"error"`
+}
+
+type pluginWindow struct {
+	Label    string     `json:"label"`
+	UsedPct  float64    `json:"used_pct"`
+	ResetsAt *time.Time `json:"resets_at"`
+}
+
+type pluginErrorBody struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+func (p Plugin) Name() string { return p.Config.Name }
+
+func (p Plugin) Fetch(ctx context.Context) Result {
+	r := Result{Name: p.Config.Name}
+
+	out, err := p.run(ctx)
+	if err != nil {
+		r.Error = err
+		r.Short = "?"
+		return r
+	}
+
+	var resp pluginResponse
+	if err := json.Unmarshal(out, &resp); err != nil {
+		r.Error = fmt.Errorf("plugin %s: invalid output: %w", p.Config.Name, err)
+		r.Short = "?"
+		return r
+	}
+
+	if resp.Error != nil {
+		msg := resp.Error.Message
+		if msg == "" {
+			msg = "plugin reported an error"
+		}
+		r.Error = fmt.Errorf("plugin %s: %s", p.Config.Name, msg)
+		r.Short = "?"
+		if resp.Error.Kind == "auth" {
+			r.Short = "!"
+		}
+		return r
+	}
+
+	r.Identity = resp.Identity
+	r.Plan = resp.Plan
+	r.Spend = resp.Spend
+	r.Credits = resp.Credits
+	r.Currency = resp.Currency
+	for _, w := range resp.Windows {
+		win := RateWindow{Label: w.Label, UsedPct: w.UsedPct}
+		if w.ResetsAt != nil {
+			win.ResetAt, win.HasReset = *w.ResetsAt, true
+		}
+		r.Windows = append(r.Windows, win)
+	}
+
+	if !summarize(&r) && resp.Short == "" {
+		r.Error = fmt.Errorf("plugin %s returned no usage data", p.Config.Name)
+		r.Short = "?"
+		return r
+	}
+	if resp.Short != "" {
+		r.Short = resp.Short
+	}
+	switch resp.Class {
+	case "normal", "warning", "critical":
+		r.Class = resp.Class
+	}
+
+	return r
+}
+
+func (p Plugin) run(ctx context.Context) ([]byte, error) {
+	if p.Config.Command == "" {
+		return nil, fmt.Errorf("plugin %s has no command", p.Config.Name)
+	}
+
+	timeout := defaultPluginTimeout
+	if p.Config.Timeout != "" {
+		d, err := time.ParseDuration(p.Config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("plugin %s: invalid timeout: %w", p.Config.Name, err)
+		}
+		timeout = d
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	settings := p.Config.Settings
+	if len(settings) == 0 {
+		settings = json.RawMessage("{}")
+	}
+	stdin, err := json.Marshal(pluginRequest{Version: 1, Name: p.Config.Name, Settings: settings})
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, config.ExpandHome(p.Config.Command), p.Config.Args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = pluginWaitDelay
+
+	// ErrWaitDelay alone means the plugin exited cleanly and only a child
+	// kept the pipes open; its output is complete.
+	if err := cmd.Run(); err != nil && !errors.Is(err, exec.ErrWaitDelay) {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("plugin %s timed out", p.Config.Name)
+		}
+		if msg := firstLine(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("plugin %s: %s", p.Config.Name, msg)
+		}
+		return nil, fmt.Errorf("plugin %s: %w", p.Config.Name, err)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(line)
+}
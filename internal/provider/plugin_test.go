@@ -0,0 +1,179 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jhartzell/ai-usage-bar/internal/config"
+)
+
+func writePlugin(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "plugin.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPluginFetchParsesResult(t *testing.T) {
+	dir := t.TempDir()
+	stdinPath := filepath.Join(dir, "stdin.json")
+	cmd := writePlugin(t, `cat > "$1"
+cat <<'JSON'
+{"identity":"ops@example.com","plan":"internal",
+ "windows":[{"label":"Daily","used_pct":80,"resets_at":"2026-03-05T00:00:00Z"}],
+ "spend":[{"label":"Today","amount":2.5}],
+ "credits":12,"currency":"EUR"}
+JSON
+`)
+
+	p := Plugin{Config: config.Provider{
+		Type:     "plugin",
+		Name:     "Gateway",
+		Command:  cmd,
+		Args:     []string{stdinPath},
+		Settings: json.RawMessage(`{"team":"ml"}`),
+	}}
+
+	r := p.Fetch(context.Background())
+	if r.Error != nil {
+		t.Fatalf("unexpected error: %v", r.Error)
+	}
+	if r.Identity != "ops@example.com" || r.Plan != "internal" {
+		t.Fatalf("unexpected identity/plan: %q %q", r.Identity, r.Plan)
+	}
+	if len(r.Windows) != 1 || !r.Windows[0].HasReset || r.Windows[0].UsedPct != 80 {
+		t.Fatalf("unexpected windows: %+v", r.Windows)
+	}
+	if r.Short != "80%" || r.Class != "warning" {
+		t.Fatalf("expected 80%% warning, got %q %q", r.Short, r.Class)
+	}
+	if len(r.Spend) != 1 || r.Spend[0].Amount != 2.5 || r.Credits == nil || *r.Credits != 12 || r.Currency != "EUR" {
+		t.Fatalf("unexpected spend/credits: %+v %v %q", r.Spend, r.Credits, r.Currency)
+	}
+
+	stdin, err := os.ReadFile(stdinPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req pluginRequest
+	if err := json.Unmarshal(stdin, &req); err != nil {
+		t.Fatalf("plugin stdin is not JSON: %v", err)
+	}
+	if req.Version != 1 || req.Name != "Gateway" || string(req.Settings) != `{"team":"ml"}` {
+		t.Fatalf("unexpected plugin request: %s", stdin)
+	}
+}
+
+func TestPluginFetchErrorKinds(t *testing.T) {
+	tests := []struct {
+		name      string
+		script    string
+		timeout   string
+		wantShort string
+		wantErr   string
+	}{
+		{
+			name:      "auth",
+			script:    `echo '{"error":{"kind":"auth","message":"token expired"}}'`,
+			wantShort: "!",
+			wantErr:   "token expired",
+		},
+		{
+			name:      "other",
+			script:    `echo '{"error":{"kind":"network","message":"gateway down"}}'`,
+			wantShort: "?",
+			wantErr:   "gateway down",
+		},
+		{
+			name:      "exit status",
+			script:    "echo 'missing GATEWAY_TOKEN' >&2\nexit 3",
+			wantShort: "?",
+			wantErr:   "missing GATEWAY_TOKEN",
+		},
+		{
+			name:      "invalid output",
+			script:    `echo 'not json'`,
+			wantShort: "?",
+			wantErr:   "invalid output",
+		},
+		{
+			name:      "timeout",
+			script:    "exec sleep 5",
+			timeout:   "100ms",
+			wantShort: "?",
+			wantErr:   "timed out",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Plugin{Config: config.Provider{Name: "Gateway", Command: writePlugin(t, tt.script), Timeout: tt.timeout}}
+			r := p.Fetch(context.Background())
+			if r.Error == nil || !strings.Contains(r.Error.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, r.Error)
+			}
+			if r.Short != tt.wantShort {
+				t.Fatalf("expected short %q, got %q", tt.wantShort, r.Short)
+			}
+		})
+	}
+}
+
+func TestPluginBackgroundChildDoesNotBlock(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		timeout string
+		wantErr string
+	}{
+		{
+			name:   "exited",
+			script: "sleep 10 &\necho '{\"short\":\"ok\"}'",
+		},
+		{
+			name:    "timed out",
+			script:  "sleep 10 &\nwait",
+			timeout: "100ms",
+			wantErr: "timed out",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Plugin{Config: config.Provider{Name: "Gateway", Command: writePlugin(t, tt.script), Timeout: tt.timeout}}
+			start := time.Now()
+			r := p.Fetch(context.Background())
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Fatalf("fetch waited %v for the background child", elapsed)
+			}
+			if tt.wantErr == "" {
+				if r.Error != nil || r.Short != "ok" {
+					t.Fatalf("expected the plugin's output, got %q (%v)", r.Short, r.Error)
+				}
+				return
+			}
+			if r.Error == nil || !strings.Contains(r.Error.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, r.Error)
+			}
+		})
+	}
+}
+
+func TestPluginShortAndClassOverride(t *testing.T) {
+	cmd := writePlugin(t, `echo '{"short":"ok","class":"critical","windows":[{"label":"Daily","used_pct":10}]}'`)
+
+	r := Plugin{Config: config.Provider{Name: "Gateway", Command: cmd}}.Fetch(context.Background())
+	if r.Error != nil {
+		t.Fatalf("unexpected error: %v", r.Error)
+	}
+	if r.Short != "ok" || r.Class != "critical" {
+		t.Fatalf("expected plugin short/class to win, got %q %q", r.Short, r.Class)
+	}
+}
@@ -0,0 +1,207 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jhartzell/ai-usage-bar/internal/config"
+)
+
+// Probe makes a minimal authenticated request and turns the rate-limit
+// headers on the response into windows. It covers API-key accounts, which
+// have no usage endpoint but report their limits on every response.
+type Probe struct {
+	Config config.Provider
+}
+
+func (p Probe) Name() string { return p.Config.Name }
+
+// probePresets are requests known to be cheap, with the vendor's header
+// names. Each costs a handful of tokens per refresh.
+var probePresets = map[string]config.Provider{
+	"anthropic": {
+		URL:    "https://api.anthropic.com/v1/messages",
+		Method: http.MethodPost,
+		Headers: map[string]string{
+			"x-api-key":         "${env:ANTHROPIC_API_KEY}",
+			"anthropic-version": "2023-06-01",
+		},
+		Body: `{"model":"claude-haiku-4-5","max_tokens":1,"messages":[{"role":"user","content":"."}]}`,
+		Limits: []config.LimitHeaders{
+			anthropicLimit("Requests", "requests"),
+			anthropicLimit("Tokens", "tokens"),
+			anthropicLimit("Input tokens", "input-tokens"),
+			anthropicLimit("Output tokens", "output-tokens"),
+		},
+	},
+	"openai": {
+		URL:    "https://api.openai.com/v1/chat/completions",
+		Method: http.MethodPost,
+		Headers: map[string]string{
+			"Authorization": "Bearer ${env:OPENAI_API_KEY}",
+		},
+		Body: `{"model":"gpt-4.1-nano","max_completion_tokens":1,"messages":[{"role":"user","content":"."}]}`,
+		Limits: []config.LimitHeaders{
+			openAILimit("Requests", "requests"),
+			openAILimit("Tokens", "tokens"),
+		},
+	},
+}
+
+func anthropicLimit(label, kind string) config.LimitHeaders {
+	return config.LimitHeaders{
+		Label:     label,
+		Limit:     "anthropic-ratelimit-" + kind + "-limit",
+		Remaining: "anthropic-ratelimit-" + kind + "-remaining",
+		Reset:     "anthropic-ratelimit-" + kind + "-reset",
+	}
+}
+
+func openAILimit(label, kind string) config.LimitHeaders {
+	return config.LimitHeaders{
+		Label:     label,
+		Limit:     "x-ratelimit-limit-" + kind,
+		Remaining: "x-ratelimit-remaining-" + kind,
+		Reset:     "x-ratelimit-reset-" + kind,
+	}
+}
+
+func (p Probe) Fetch(ctx context.Context) Result {
+	r := Result{Name: p.Config.Name}
+
+	pc, err := p.resolve()
+	if err != nil {
+		r.Error = err
+		r.Short = "?"
+		return r
+	}
+
+	req, err := newConfigRequest(ctx, pc)
+	if err != nil {
+		r.Error = err
+		r.Short = "?"
+		return r
+	}
+
+	// Sent once, not through httpClient: a 429 is a reading here rather
+	// than a failure, and every retry would cost tokens.
+	resp, err := send(req)
+	if err != nil {
+		r.Error = err
+		r.Short = "?"
+		return r
+	}
+	drainClose(resp) // only the headers matter
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		r.Error = fmt.Errorf("%s credentials rejected (HTTP %d)", p.Config.Name, resp.StatusCode)
+		r.Short = "!"
+		return r
+	}
+
+	// A 429 still carries the headers, and is exactly when they matter.
+	now := time.Now()
+	for _, l := range pc.Limits {
+		if w, ok := probeWindow(resp.Header, l, now); ok {
+			r.Windows = append(r.Windows, w)
+		}
+	}
+
+	if len(r.Windows) == 0 {
+		if resp.StatusCode/100 != 2 {
+			r.Error = fmt.Errorf("HTTP %d", resp.StatusCode)
+		} else {
+			r.Error = fmt.Errorf("no rate-limit headers in response")
+		}
+		r.Short = "?"
+		return r
+	}
+
+	summarize(&r)
+	return r
+}
+
+// resolve applies the preset, if any, under the configured fields: URL,
+// method and body replace the preset's, headers are merged, and limits
+// replace the preset's when given.
+func (p Probe) resolve() (config.Provider, error) {
+	pc := p.Config
+	if pc.Preset == "" {
+		if pc.URL == "" || len(pc.Limits) == 0 {
+			return pc, fmt.Errorf("probe %s needs a preset, or a url and limits", pc.Name)
+		}
+		return pc, nil
+	}
+
+	preset, ok := probePresets[pc.Preset]
+	if !ok {
+		return pc, fmt.Errorf("unknown probe preset %q", pc.Preset)
+	}
+
+	out := preset
+	out.Name = pc.Name
+	if pc.URL != "" {
+		out.URL = pc.URL
+	}
+	if pc.Method != "" {
+		out.Method = pc.Method
+	}
+	if pc.Body != "" {
+		out.Body = pc.Body
+	}
+	out.Headers = map[string]string{}
+	for k, v := range preset.Headers {
+		out.Headers[k] = v
+	}
+	for k, v := range pc.Headers {
+		out.Headers[k] = v
+	}
+	if len(pc.Limits) > 0 {
+		out.Limits = pc.Limits
+	}
+	return out, nil
+}
+
+func probeWindow(h http.Header, l config.LimitHeaders, now time.Time) (RateWindow, bool) {
+	limit, err := strconv.ParseFloat(strings.TrimSpace(h.Get(l.Limit)), 64)
+	if err != nil || limit <= 0 {
+		return RateWindow{}, false
+	}
+	remaining, err := strconv.ParseFloat(strings.TrimSpace(h.Get(l.Remaining)), 64)
+	if err != nil {
+		return RateWindow{}, false
+	}
+
+	w := RateWindow{Label: l.Label, UsedPct: (limit - remaining) / limit * 100}
+	if l.Reset != "" {
+		w.ResetAt, w.HasReset = parseResetHeader(h.Get(l.Reset), now)
+	}
+	return w, true
+}
+
+// parseResetHeader accepts the reset formats vendors use: an RFC 3339 time
+// (Anthropic), a Go-style duration such as "6m0s" or "20ms" (OpenAI), or a
+// number, read as a unix timestamp when large and as seconds otherwise.
+func parseResetHeader(v string, now time.Time) (time.Time, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return time.Time{}, false
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, true
+	}
+	if n, err := strconv.ParseFloat(v, 64); err == nil {
+		if n > 1e9 {
+			return parseResetValue(n)
+		}
+		return now.Add(time.Duration(n * float64(time.Second))), true
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return now.Add(d), true
+	}
+	return time.Time{}, false
+}
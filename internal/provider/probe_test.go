@@ -0,0 +1,148 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jhartzell/ai-usage-bar/internal/config"
+)
+
+func TestProbeAnthropicPreset(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant-test")
+	reset := time.Now().Add(30 * time.Second).UTC().Truncate(time.Second)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost || req.Header.Get("x-api-key") != "sk-ant-test" {
+			t.Fatalf("unexpected request: %s %v", req.Method, req.Header)
+		}
+		if req.Header.Get("anthropic-version") == "" {
+			t.Fatal("expected anthropic-version header from preset")
+		}
+		h := w.Header()
+		h.Set("anthropic-ratelimit-requests-limit", "50")
+		h.Set("anthropic-ratelimit-requests-remaining", "49")
+		h.Set("anthropic-ratelimit-requests-reset", reset.Format(time.RFC3339))
+		h.Set("anthropic-ratelimit-input-tokens-limit", "40000")
+		h.Set("anthropic-ratelimit-input-tokens-remaining", "8000")
+		writeJSON(w, `{"content":[]}`)
+	}))
+	defer srv.Close()
+
+	r := Probe{Config: config.Provider{Type: "probe", Name: "Anthropic API", Preset: "anthropic", URL: srv.URL}}.Fetch(context.Background())
+	if r.Error != nil {
+		t.Fatalf("unexpected error: %v", r.Error)
+	}
+	if len(r.Windows) != 2 {
+		t.Fatalf("expected requests and input token windows, got %+v", r.Windows)
+	}
+	if w := r.Windows[0]; w.Label != "Requests" || !approxEqual(w.UsedPct, 2) || !w.HasReset || !w.ResetAt.Equal(reset) {
+		t.Fatalf("unexpected requests window: %+v", w)
+	}
+	if w := r.Windows[1]; w.Label != "Input tokens" || !approxEqual(w.UsedPct, 80) || w.HasReset {
+		t.Fatalf("unexpected input tokens window: %+v", w)
+	}
+	if r.Short != "80%" || r.Class != "warning" {
+		t.Fatalf("unexpected summary: %q %q", r.Short, r.Class)
+	}
+}
+
+func TestProbeOpenAIRateLimitedStillReportsWindows(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer sk-test" {
+			t.Fatalf("unexpected auth header: %q", req.Header.Get("Authorization"))
+		}
+		h := w.Header()
+		h.Set("x-ratelimit-limit-requests", "500")
+		h.Set("x-ratelimit-remaining-requests", "0")
+		h.Set("x-ratelimit-reset-requests", "6m0s")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	before := time.Now()
+	r := Probe{Config: config.Provider{Name: "OpenAI API", Preset: "openai", URL: srv.URL}}.Fetch(context.Background())
+	if r.Error != nil {
+		t.Fatalf("unexpected error: %v", r.Error)
+	}
+	if len(r.Windows) != 1 || r.Short != "100%" || r.Class != "critical" {
+		t.Fatalf("unexpected result: %+v", r)
+	}
+	if w := r.Windows[0]; !w.HasReset || w.ResetAt.Before(before.Add(6*time.Minute)) {
+		t.Fatalf("unexpected reset: %+v", w)
+	}
+}
+
+func TestProbeCustomLimitsAndErrors(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if status == http.StatusOK {
+			w.Header().Set("X-RateLimit-Limit", "1000")
+			w.Header().Set("X-RateLimit-Remaining", "750")
+			w.Header().Set("X-RateLimit-Reset", "1767225600")
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	p := Probe{Config: config.Provider{
+		Name: "Gateway",
+		URL:  srv.URL,
+		Limits: []config.LimitHeaders{
+			{Label: "Hourly", Limit: "X-RateLimit-Limit", Remaining: "X-RateLimit-Remaining", Reset: "X-RateLimit-Reset"},
+		},
+	}}
+
+	r := p.Fetch(context.Background())
+	if r.Error != nil || len(r.Windows) != 1 || r.Short != "25%" {
+		t.Fatalf("unexpected result: %+v", r)
+	}
+	if !r.Windows[0].ResetAt.Equal(time.Unix(1767225600, 0)) {
+		t.Fatalf("unexpected reset: %v", r.Windows[0].ResetAt)
+	}
+
+	status = http.StatusUnauthorized
+	if r := p.Fetch(context.Background()); r.Short != "!" || r.Error == nil {
+		t.Fatalf("expected auth failure, got %+v", r)
+	}
+
+	status = http.StatusBadGateway
+	if r := p.Fetch(context.Background()); r.Short != "?" || r.Error == nil || r.Error.Error() != "HTTP 502" {
+		t.Fatalf("expected HTTP error, got %+v", r)
+	}
+
+	if r := (Probe{Config: config.Provider{Name: "X", Preset: "nope"}}).Fetch(context.Background()); r.Error == nil {
+		t.Fatal("expected unknown preset error")
+	}
+	if r := (Probe{Config: config.Provider{Name: "X", URL: srv.URL}}).Fetch(context.Background()); r.Error == nil {
+		t.Fatal("expected missing limits error")
+	}
+}
+
+func TestParseResetHeader(t *testing.T) {
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		in   string
+		want time.Time
+		ok   bool
+	}{
+		{in: "2026-03-04T12:01:00Z", want: now.Add(time.Minute), ok: true},
+		{in: "1m30s", want: now.Add(90 * time.Second), ok: true},
+		{in: "20ms", want: now.Add(20 * time.Millisecond), ok: true},
+		{in: "7.5", want: now.Add(7500 * time.Millisecond), ok: true},
+		{in: "1772625600", want: time.Unix(1772625600, 0), ok: true},
+		{in: "soon", ok: false},
+		{in: "", ok: false},
+	}
+	for _, tt := range tests {
+		got, ok := parseResetHeader(tt.in, now)
+		if ok != tt.ok || (ok && !got.Equal(tt.want)) {
+			t.Fatalf("parseResetHeader(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -2,8 +2,17 @@ package provider
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/jhartzell/ai-usage-bar/internal/config"
+	"github.com/jhartzell/ai-usage-bar/internal/diag"
 )
 
 type RateWindow struct {
@@ -14,20 +23,80 @@ type RateWindow struct {
 }
 
 type SpendEntry struct {
-	Label  string
-	Amount float64
+	Label    string
+	Amount   float64
+	Estimate bool // computed from token counts and list prices, not billed
+}
+
+// KeyUsage describes one API key on an account, as listed by a
+// management/provisioning key.
+type KeyUsage struct {
+	Label     string
+	Limit     *float64
+	Remaining *float64
+	Daily     float64
+	Weekly    float64
+	Monthly   float64
+	Disabled  bool
+	Class     string // "normal", "warning", "critical"
+}
+
+// BreakdownEntry is one row of a drill-down table, e.g. spend for a single
+// model over a period. Group names the table the row belongs to.
+type BreakdownEntry struct {
+	Group    string
+	Label    string
+	Amount   float64
+	Requests int
+	Tokens   int64
 }
 
 type Result struct {
-	Name     string
-	Identity string // email, key label, or account name
-	Short    string // e.g. "42%" or "$1.23"
-	Class    string // "normal", "warning", "critical"
-	Windows  []RateWindow
-	Spend    []SpendEntry
-	Credits  *float64
-	Plan     string
-	Error    error
+	Name      string
+	Identity  string // email, key label, or account name
+	Short     string // e.g. "42%" or "$1.23"
+	Class     string // "normal", "warning", "critical"
+	Windows   []RateWindow
+	Spend     []SpendEntry
+	Keys      []KeyUsage
+	Breakdown []BreakdownEntry
+	Credits   *float64
+	Currency  string // ISO 4217 code for Spend and Credits; empty means USD
+	Tokens    []TokenPeriod
+	Plan      string
+	Error     error
+
+	// Source names where the data came from when it isn't the live API,
+	// e.g. "session log", and AsOf is when that data was recorded.
+	Source string
+	AsOf   time.Time
+}
+
+// TokenCount counts tokens by kind.
+type TokenCount struct {
+	Input      int64
+	Output     int64
+	CacheWrite int64
+	CacheRead  int64
+}
+
+func (t TokenCount) Total() int64 {
+	return t.Input + t.Output + t.CacheWrite + t.CacheRead
+}
+
+// TokenRow is a token total for one model or project.
+type TokenRow struct {
+	Label string
+	TokenCount
+}
+
+// TokenPeriod is locally counted token usage for a period such as "Today",
+// split by model and by project.
+type TokenPeriod struct {
+	Label string
+	TokenCount
+	Models   []TokenRow
+	Projects []TokenRow
 }
 
 type Provider interface {
@@ -35,6 +104,101 @@ type Provider interface {
 	Fetch(ctx context.Context) Result
 }
 
+// Optional is implemented by providers that are only shown once their
+// credentials are present, so vendors a user doesn't have stay out of the bar.
+type Optional interface {
+	Configured() bool
+}
+
+// Default returns the built-in providers followed by those declared in the
+// config file. Claude, Codex and OpenRouter are always listed, the other
+// built-ins only when Configured reports true. While replaying, the
+// providers the tape has exchanges for are listed instead, configured or
+// not, along with any configuration problems.
+func Default() []Provider {
+	all := []Provider{
+		Claude{},
+		Codex{},
+		OpenRouter{},
+		AnthropicAdmin{},
+		OpenAI{},
+		Gemini{},
+		Copilot{},
+		Cursor{},
+		DeepSeek(),
+		Moonshot(),
+		SiliconFlow(),
+		LiteLLM{},
+	}
+
+	if replaying() {
+		var providers []Provider
+		for _, p := range append(all, fromConfig()...) {
+			if _, ok := p.(configError); ok || replayedProvider(p.Name()) {
+				providers = append(providers, p)
+			}
+		}
+		return providers
+	}
+
+	providers := make([]Provider, 0, len(all))
+	for _, p := range all {
+		if opt, ok := p.(Optional); ok && !opt.Configured() {
+			continue
+		}
+		providers = append(providers, p)
+	}
+	return append(providers, fromConfig()...)
+}
+
+// fromConfig builds the providers declared in the config file. Problems are
+// reported as a failing provider so they show up in the bar rather than
+// being silently ignored.
+func fromConfig() []Provider {
+	cfg, err := config.Load()
+	if err != nil {
+		return []Provider{configError{name: "Config", err: err}}
+	}
+
+	providers := make([]Provider, 0, len(cfg.Providers)+2)
+	if err := ConfigureNetwork(cfg.Network); err != nil {
+		providers = append(providers, configError{name: "Network", err: err})
+	}
+	if err := ConfigureEndpoints(cfg.Endpoints); err != nil {
+		providers = append(providers, configError{name: "Endpoints", err: err})
+	}
+	for _, pc := range cfg.Providers {
+		switch pc.Type {
+		case "generic":
+			providers = append(providers, Generic{Config: pc})
+		case "plugin":
+			providers = append(providers, Plugin{Config: pc})
+		case "probe":
+			providers = append(providers, Probe{Config: pc})
+		default:
+			providers = append(providers, configError{
+				name: pc.Name,
+				err:  fmt.Errorf("unknown provider type %q in config", pc.Type),
+			})
+		}
+	}
+	return providers
+}
+
+type configError struct {
+	name string
+	err  error
+}
+
+func (c configError) Name() string { return c.name }
+
+func (c configError) Fetch(context.Context) Result {
+	return Result{Name: c.name, Short: "?", Error: c.err}
+}
+
+// ConfigErr returns the configuration problem this placeholder reports.
+func (c configError) ConfigErr() error { return c.err }
+
 func FetchAll(ctx context.Context, providers []Provider) []Result {
 	results := make([]Result, len(providers))
 	var wg sync.WaitGroup
@@ -43,9 +207,11 @@ func FetchAll(ctx context.Context, providers []Provider) []Result {
 		wg.Add(1)
 		go func(idx int, prov Provider) {
 			defer wg.Done()
-			fetchCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			fetchCtx, cancel := context.WithTimeout(withProviderName(ctx, prov.Name()), 5*time.Second)
 			defer cancel()
+			start := time.Now()
 			results[idx] = prov.Fetch(fetchCtx)
+			logResult(results[idx], time.Since(start))
 		}(i, p)
 	}
 
@@ -53,6 +219,70 @@ func FetchAll(ctx context.Context, providers []Provider) []Result {
 	return results
 }
 
+func logResult(r Result, elapsed time.Duration) {
+	elapsed = elapsed.Round(time.Millisecond)
+	if r.Error != nil {
+		diag.Logf("%s: failed in %v: %v", r.Name, elapsed, r.Error)
+		return
+	}
+	diag.Logf("%s: fetched in %v: %s", r.Name, elapsed, r.Short)
+}
+
+// logRefresh records the outcome of an OAuth token refresh; the request
+// itself is logged by send.
+func logRefresh(ctx context.Context, err error) {
+	if err != nil {
+		diag.Logf("%s: token refresh failed: %v", providerName(ctx), err)
+		return
+	}
+	diag.Logf("%s: token refreshed", providerName(ctx))
+}
+
+// userConfigDir returns $XDG_CONFIG_HOME, defaulting to ~/.config, where
+// editor-based tools keep their logins on Linux.
+func userConfigDir() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config"), nil
+}
+
+// decodeJWTClaims unmarshals a JWT's payload into v without verifying the
+// signature; callers only use the claims as display labels or ids.
+func decodeJWTClaims(token string, v any) error {
+	parts := strings.Split(token, ".")
+	if len(parts) < 2 {
+		return fmt.Errorf("malformed JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(payload, v)
+}
+
+// CurrencySymbol returns the display prefix for an ISO 4217 currency code.
+// Empty means USD.
+func CurrencySymbol(code string) string {
+	switch strings.ToUpper(code) {
+	case "", "USD":
+		return "$"
+	case "CNY":
+		return "¥"
+	case "EUR":
+		return "€"
+	case "GBP":
+		return "£"
+	default:
+		return strings.ToUpper(code) + " "
+	}
+}
+
 func classFromPct(pct float64) string {
 	switch {
 	case pct >= 90:
@@ -64,6 +294,24 @@ func classFromPct(pct float64) string {
 	}
 }
 
+func worstClass(a, b string) string {
+	if classRank(b) > classRank(a) {
+		return b
+	}
+	return a
+}
+
+func classRank(class string) int {
+	switch class {
+	case "critical":
+		return 2
+	case "warning":
+		return 1
+	default:
+		return 0
+	}
+}
+
 func formatResetDuration(d time.Duration) string {
 	if d <= 0 {
 		return "now"
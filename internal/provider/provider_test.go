@@ -3,6 +3,9 @@ package provider
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -141,3 +144,64 @@ func TestItoa(t *testing.T) {
 		}
 	}
 }
+
+func TestDefaultSkipsUnconfiguredOptionalProviders(t *testing.T) {
+	t.Setenv("ANTHROPIC_ADMIN_KEY", "")
+	t.Setenv("OPENAI_ADMIN_KEY", "")
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("CURSOR_SESSION_TOKEN", "")
+	t.Setenv("DEEPSEEK_API_KEY", "")
+	t.Setenv("MOONSHOT_API_KEY", "")
+	t.Setenv("SILICONFLOW_API_KEY", "")
+	t.Setenv("LITELLM_API_KEY", "")
+
+	names := providerNames(Default())
+	if names != "Claude,Codex,OpenRouter" {
+		t.Fatalf("unexpected default providers: %s", names)
+	}
+
+	t.Setenv("ANTHROPIC_ADMIN_KEY", "sk-ant-admin-test")
+	names = providerNames(Default())
+	if names != "Claude,Codex,OpenRouter,Anthropic API" {
+		t.Fatalf("expected configured optional provider, got %s", names)
+	}
+}
+
+func TestDefaultAppendsConfiguredProviders(t *testing.T) {
+	t.Setenv("ANTHROPIC_ADMIN_KEY", "")
+	t.Setenv("OPENAI_ADMIN_KEY", "")
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("CURSOR_SESSION_TOKEN", "")
+	t.Setenv("DEEPSEEK_API_KEY", "")
+	t.Setenv("MOONSHOT_API_KEY", "")
+	t.Setenv("SILICONFLOW_API_KEY", "")
+	t.Setenv("LITELLM_API_KEY", "")
+
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	if err := os.MkdirAll(filepath.Join(dir, "ai-usage-bar"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	config := `{"providers":[{"type":"generic","name":"Gateway","url":"http://gw"},{"type":"bogus","name":"Broken"}]}`
+	if err := os.WriteFile(filepath.Join(dir, "ai-usage-bar", "config.json"), []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	providers := Default()
+	if names := providerNames(providers); names != "Claude,Codex,OpenRouter,Gateway,Broken" {
+		t.Fatalf("unexpected providers: %s", names)
+	}
+	r := providers[len(providers)-1].Fetch(context.Background())
+	if r.Error == nil || r.Short != "?" {
+		t.Fatalf("expected unknown type error, got %q %v", r.Short, r.Error)
+	}
+}
+
+func providerNames(providers []Provider) string {
+	names := make([]string, 0, len(providers))
+	for _, p := range providers {
+		names = append(names, p.Name())
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,99 @@
+package provider
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/jhartzell/ai-usage-bar/internal/tape"
+)
+
+// tapes holds the --record or --replay state; at most one is set.
+var tapes struct {
+	sync.RWMutex
+	recorder *tape.Recorder
+	player   *http.Client
+	// replayed lists the providers the tape being replayed has exchanges
+	// for.
+	replayed []string
+}
+
+// replayCredential stands in for every credential while replaying. The
+// tape's own are redacted, so any value matches, and a tape plays back on
+// a machine that has none.
+const replayCredential = "replay-placeholder"
+
+// Record saves every provider request and its response, sanitized, to dir
+// as JSON fixtures that Replay can serve back.
+func Record(dir string) error {
+	rec, err := tape.NewRecorder(dir)
+	if err != nil {
+		return err
+	}
+	rec.Name = func(req *http.Request) string { return providerName(req.Context()) }
+
+	tapes.Lock()
+	tapes.recorder, tapes.player = rec, nil
+	tapes.Unlock()
+	return nil
+}
+
+// Replay answers provider requests from the fixtures in dir instead of the
+// network. Providers use placeholder credentials instead of local ones,
+// and Default lists exactly the providers in the tape. Refreshed tokens in
+// a tape are redacted placeholders, so credentials are not saved while
+// replaying.
+func Replay(dir string) error {
+	p, err := tape.Load(dir)
+	if err != nil {
+		return err
+	}
+
+	tapes.Lock()
+	tapes.recorder, tapes.player = nil, &http.Client{Transport: p}
+	tapes.replayed = p.Providers()
+	tapes.Unlock()
+	return nil
+}
+
+// stopTape goes back to live, unrecorded requests.
+func stopTape() {
+	tapes.Lock()
+	tapes.recorder, tapes.player, tapes.replayed = nil, nil, nil
+	tapes.Unlock()
+}
+
+func replaying() bool {
+	tapes.RLock()
+	defer tapes.RUnlock()
+	return tapes.player != nil
+}
+
+// replayedProvider reports whether the tape being replayed has exchanges
+// for the named provider.
+func replayedProvider(name string) bool {
+	tapes.RLock()
+	defer tapes.RUnlock()
+	return slices.Contains(tapes.replayed, name)
+}
+
+// credential returns key, or the placeholder while replaying.
+func credential(key string) string {
+	if replaying() {
+		return replayCredential
+	}
+	return key
+}
+
+func tapeClient(c *http.Client) *http.Client {
+	tapes.RLock()
+	defer tapes.RUnlock()
+
+	switch {
+	case tapes.player != nil:
+		return tapes.player
+	case tapes.recorder != nil:
+		return &http.Client{Transport: tapes.recorder.Transport(c.Transport), Timeout: c.Timeout}
+	}
+	return c
+}
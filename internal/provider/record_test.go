@@ -0,0 +1,114 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// replayTape serves provider requests from testdata/replay/<name> for the
+// rest of the test.
+func replayTape(t *testing.T, name string) {
+	t.Helper()
+	if err := Replay(filepath.Join("testdata", "replay", name)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(stopTape)
+}
+
+// writeCredentials writes a credentials file below a fresh HOME and
+// returns its path.
+func writeCredentials(t *testing.T, rel, content string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(home, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	resetEndpoints(t)
+	t.Cleanup(stopTape)
+	t.Setenv("OPENROUTER_API_KEY", "sk-or-v1-0123456789abcdefghij")
+	t.Setenv("OPENROUTER_PROVISIONING_KEY", "")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/api/v1/key":
+			writeJSON(w, `{"data":{"label":"sk-or-v1-0123456789abcdefghij","limit":100,"limit_remaining":40,"usage":60}}`)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	t.Setenv("AI_USAGE_BAR_OPENROUTER_URL", srv.URL+"/api/v1")
+
+	dir := t.TempDir()
+	if err := Record(dir); err != nil {
+		t.Fatal(err)
+	}
+	live := FetchAll(context.Background(), []Provider{OpenRouter{}})[0]
+	if live.Error != nil {
+		t.Fatalf("unexpected error: %v", live.Error)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) == 0 {
+		t.Fatal("nothing recorded")
+	}
+	for _, f := range files {
+		data, _ := os.ReadFile(f)
+		if strings.Contains(string(data), "0123456789abcdefghij") {
+			t.Fatalf("%s leaks the API key:\n%s", filepath.Base(f), data)
+		}
+	}
+	if !strings.Contains(filepath.Base(files[0]), "-openrouter-get-") {
+		t.Fatalf("unexpected fixture name %s", filepath.Base(files[0]))
+	}
+
+	srv.Close()
+	if err := Replay(dir); err != nil {
+		t.Fatal(err)
+	}
+	replayed := FetchAll(context.Background(), []Provider{OpenRouter{}})[0]
+	if replayed.Error != nil || replayed.Short != live.Short {
+		t.Fatalf("replay differs from the recording: %+v vs %+v", replayed, live)
+	}
+}
+
+func TestReplayListsTapeProvidersWithoutLocalCredentials(t *testing.T) {
+	resetEndpoints(t)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	replayTape(t, "claude-refresh-after-401")
+
+	providers := Default()
+	if names := providerNames(providers); names != "Claude" {
+		t.Fatalf("expected only the tape's providers, got %s", names)
+	}
+	r := FetchAll(context.Background(), providers)[0]
+	if r.Error != nil || r.Short != "63%" {
+		t.Fatalf("expected the recorded result, got %+v", r)
+	}
+}
+
+func TestReplayUnknownRequest(t *testing.T) {
+	resetEndpoints(t)
+	replayTape(t, "codex-refresh-after-401")
+	t.Setenv("AI_USAGE_BAR_CLAUDE_URL", "")
+
+	_, _, err := fetchClaudeUsage(context.Background(), "token")
+	if err == nil || !strings.Contains(err.Error(), "no recorded response for GET https://api.anthropic.com/api/oauth/usage") {
+		t.Fatalf("expected a missing recording error, got %v", err)
+	}
+}
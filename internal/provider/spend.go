@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// spendPeriodLabels are the Spend rows reported by cost-based providers, in
+// display order. They match the labels OpenRouter uses for its own totals.
+var spendPeriodLabels = []string{"Today", "This week", "This month"}
+
+// spendPeriods holds the UTC boundaries of the current day, week (starting
+// Monday) and calendar month.
+type spendPeriods struct {
+	dayStart   time.Time
+	weekStart  time.Time
+	monthStart time.Time
+}
+
+func newSpendPeriods(now time.Time) spendPeriods {
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	offset := (int(day.Weekday()) + 6) % 7
+	return spendPeriods{
+		dayStart:   day,
+		weekStart:  day.AddDate(0, 0, -offset),
+		monthStart: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// start returns the earliest boundary, i.e. where a report query must begin
+// to cover every period.
+func (p spendPeriods) start() time.Time {
+	if p.weekStart.Before(p.monthStart) {
+		return p.weekStart
+	}
+	return p.monthStart
+}
+
+// containing returns the labels of every period that includes t.
+func (p spendPeriods) containing(t time.Time) []string {
+	var labels []string
+	if !t.Before(p.dayStart) {
+		labels = append(labels, "Today")
+	}
+	if !t.Before(p.weekStart) {
+		labels = append(labels, "This week")
+	}
+	if !t.Before(p.monthStart) {
+		labels = append(labels, "This month")
+	}
+	return labels
+}
+
+// addBreakdown accumulates amount and tokens into the row for label within
+// group, creating either as needed.
+func addBreakdown(groups map[string]map[string]*BreakdownEntry, group, label string, amount float64, tokens int64) {
+	rows := groups[group]
+	if rows == nil {
+		rows = map[string]*BreakdownEntry{}
+		groups[group] = rows
+	}
+	e := rows[label]
+	if e == nil {
+		e = &BreakdownEntry{Group: group, Label: label}
+		rows[label] = e
+	}
+	e.Amount += amount
+	e.Tokens += tokens
+}
+
+// topBreakdown returns up to n rows sorted by amount, then tokens, then label.
+func topBreakdown(rows map[string]*BreakdownEntry, n int) []BreakdownEntry {
+	entries := make([]BreakdownEntry, 0, len(rows))
+	for _, e := range rows {
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Amount != entries[j].Amount {
+			return entries[i].Amount > entries[j].Amount
+		}
+		if entries[i].Tokens != entries[j].Tokens {
+			return entries[i].Tokens > entries[j].Tokens
+		}
+		return entries[i].Label < entries[j].Label
+	})
+
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
+
+// readBody reads a response body, capped so a misbehaving endpoint can't
+// exhaust memory.
+func readBody(resp *http.Response) ([]byte, error) {
+	return io.ReadAll(io.LimitReader(resp.Body, 8<<20))
+}
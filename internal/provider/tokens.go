@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/jhartzell/ai-usage-bar/internal/transcripts"
+)
+
+// tokenTopProjects caps the projects kept per period; models are few
+// enough to keep them all.
+const tokenTopProjects = 5
+
+// claudeTokenUsage counts tokens from local Claude Code transcripts for
+// today, the session window and the week. Window starts come from the
+// usage API's reset times when available, else rolling 5h and 7d periods,
+// so it also works offline. It returns nil when there are no transcripts.
+func claudeTokenUsage(ctx context.Context, windows []RateWindow, now time.Time) []TokenPeriod {
+	dir, err := transcripts.ClaudeDir()
+	if err != nil {
+		return nil
+	}
+
+	buckets, _ := transcripts.Claude(dir).Scan(ctx, now)
+	if len(buckets) == 0 {
+		return nil
+	}
+
+	session := now.Add(-5 * time.Hour)
+	week := now.Add(-7 * 24 * time.Hour)
+	for _, w := range windows {
+		if !w.HasReset || !w.ResetAt.After(now) {
+			continue
+		}
+		switch w.Label {
+		case "Session (5h)":
+			session = w.ResetAt.Add(-5 * time.Hour)
+		case "Weekly (7d)":
+			week = w.ResetAt.Add(-7 * 24 * time.Hour)
+		}
+	}
+
+	y, m, d := now.Date()
+	return tokenPeriods(transcripts.Summarize(buckets, []transcripts.Period{
+		{Label: "Today", Since: time.Date(y, m, d, 0, 0, 0, 0, now.Location())},
+		{Label: "Session (5h)", Since: session},
+		{Label: "Weekly (7d)", Since: week},
+	}))
+}
+
+// codexTokenUsage counts tokens from local Codex CLI session logs for
+// today and the last 7 and 30 days. It returns nil when there are no logs.
+func codexTokenUsage(ctx context.Context, now time.Time) []TokenPeriod {
+	dir, err := transcripts.CodexDir()
+	if err != nil {
+		return nil
+	}
+
+	buckets, _ := transcripts.Codex(dir).Scan(ctx, now)
+	if len(buckets) == 0 {
+		return nil
+	}
+
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, now.Location())
+	return tokenPeriods(transcripts.Summarize(buckets, []transcripts.Period{
+		{Label: "Today", Since: today},
+		{Label: "Last 7 days", Since: today.AddDate(0, 0, -6)},
+		{Label: "Last 30 days", Since: today.AddDate(0, 0, -29)},
+	}))
+}
+
+func tokenPeriods(summaries []transcripts.Summary) []TokenPeriod {
+	periods := make([]TokenPeriod, 0, len(summaries))
+	for _, s := range summaries {
+		p := TokenPeriod{Label: s.Label, TokenCount: tokenCount(s.Tokens)}
+		for _, r := range s.Models {
+			p.Models = append(p.Models, TokenRow{Label: r.Label, TokenCount: tokenCount(r.Tokens)})
+		}
+		for i, r := range s.Projects {
+			if i == tokenTopProjects {
+				break
+			}
+			p.Projects = append(p.Projects, TokenRow{Label: r.Label, TokenCount: tokenCount(r.Tokens)})
+		}
+		periods = append(periods, p)
+	}
+	return periods
+}
+
+func tokenCount(t transcripts.Tokens) TokenCount {
+	return TokenCount{Input: t.Input, Output: t.Output, CacheWrite: t.CacheWrite, CacheRead: t.CacheRead}
+}
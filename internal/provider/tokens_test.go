@@ -0,0 +1,85 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestClaudeTokenUsagePeriods(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	dir := filepath.Join(home, ".claude", "projects", "-home-dev-repo")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	lines := `{"type":"assistant","timestamp":"2026-03-02T12:00:00Z","cwd":"/home/dev/repo","message":{"id":"m1","model":"claude-opus","usage":{"input_tokens":1,"output_tokens":1000}}}
+{"type":"assistant","timestamp":"2026-03-04T06:00:00Z","cwd":"/home/dev/repo","message":{"id":"m2","model":"claude-sonnet","usage":{"input_tokens":10,"output_tokens":100}}}
+{"type":"assistant","timestamp":"2026-03-04T11:00:00Z","cwd":"/home/dev/repo","message":{"id":"m3","model":"claude-sonnet","usage":{"input_tokens":10,"output_tokens":10}}}
+`
+	if err := os.WriteFile(filepath.Join(dir, "s.jsonl"), []byte(lines), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+	windows := []RateWindow{
+		{Label: "Session (5h)", HasReset: true, ResetAt: now.Add(3 * time.Hour)}, // window began 10:00
+	}
+
+	periods := claudeTokenUsage(context.Background(), windows, now)
+	if len(periods) != 3 {
+		t.Fatalf("expected three periods, got %+v", periods)
+	}
+
+	want := map[string]int64{"Today": 130, "Session (5h)": 20, "Weekly (7d)": 1131}
+	for _, p := range periods {
+		if p.Total() != want[p.Label] {
+			t.Fatalf("%s: got %d tokens, want %d", p.Label, p.Total(), want[p.Label])
+		}
+	}
+	if week := periods[2]; week.Models[0].Label != "claude-opus" || week.Projects[0].Label != "/home/dev/repo" {
+		t.Fatalf("unexpected weekly rows: %+v", week)
+	}
+}
+
+func TestClaudeTokenUsageWithoutTranscripts(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	if periods := claudeTokenUsage(context.Background(), nil, time.Now()); periods != nil {
+		t.Fatalf("expected no token periods, got %+v", periods)
+	}
+}
+
+func TestCodexTokenUsagePeriods(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	dir := filepath.Join(home, ".codex", "sessions", "2026", "03", "01")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	log := `{"timestamp":"2026-03-01T09:00:00Z","type":"turn_context","payload":{"cwd":"/home/dev/api","model":"gpt-5-codex"}}
+{"timestamp":"2026-03-01T09:00:05Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"total_tokens":60},"last_token_usage":{"input_tokens":50,"output_tokens":10,"total_tokens":60}}}}
+{"timestamp":"2026-03-04T09:00:05Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"total_tokens":66},"last_token_usage":{"input_tokens":5,"output_tokens":1,"total_tokens":6}}}}
+`
+	if err := os.WriteFile(filepath.Join(dir, "rollout-1.jsonl"), []byte(log), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	periods := codexTokenUsage(context.Background(), time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC))
+	if len(periods) != 3 {
+		t.Fatalf("expected three periods, got %+v", periods)
+	}
+	if periods[0].Total() != 6 || periods[1].Total() != 66 || periods[2].Total() != 66 {
+		t.Fatalf("unexpected totals: %d %d %d", periods[0].Total(), periods[1].Total(), periods[2].Total())
+	}
+	if p := periods[1].Projects; len(p) != 1 || p[0].Label != "/home/dev/api" {
+		t.Fatalf("unexpected projects: %+v", p)
+	}
+}
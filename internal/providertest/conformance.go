@@ -0,0 +1,240 @@
+package providertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jhartzell/ai-usage-bar/internal/provider"
+)
+
+// Fake is a fake vendor API a provider can be pointed at. *Server
+// implements it; a new provider can bring its own.
+type Fake interface {
+	// Set switches the fake to a scenario.
+	Set(Scenario)
+	// Install points the provider at the fake and writes its credentials.
+	Install(testing.TB)
+	// Tokens returns the credentials the fake accepts now.
+	Tokens() (access, refresh string)
+}
+
+// Conformance describes a provider for RunConformance.
+type Conformance struct {
+	// Provider is the implementation under test.
+	Provider provider.Provider
+	// Fake returns a fresh fake API for one case.
+	Fake func(testing.TB) Fake
+	// Secrets lists credentials beyond the fake's tokens that must never
+	// appear in an error, e.g. a client secret from the environment.
+	Secrets []string
+}
+
+// conformanceTimeout stands in for FetchAll's per-provider timeout in the
+// timeout case, so the suite stays fast.
+const conformanceTimeout = 300 * time.Millisecond
+
+// conformanceCase is one scenario and what every provider must make of
+// it.
+type conformanceCase struct {
+	name     string
+	scenario Scenario
+	// fetch runs the provider; nil means through provider.FetchAll.
+	fetch func(provider.Provider) provider.Result
+	check func(provider.Result) []string
+}
+
+var conformanceCases = []conformanceCase{
+	{
+		name:  "healthy",
+		check: checkHealthy,
+	},
+	{
+		name:     "expired token",
+		scenario: Scenario{ExpiredToken: true, RotateTokens: true},
+		// OAuth providers refresh and retry; API-key providers can't, and
+		// report it as an auth failure.
+		check: func(r provider.Result) []string {
+			if r.Error != nil {
+				return checkShort(r, "!")
+			}
+			return checkHealthy(r)
+		},
+	},
+	{
+		name:     "revoked credentials",
+		scenario: Scenario{Revoked: true},
+		check: func(r provider.Result) []string {
+			return checkShort(r, "!")
+		},
+	},
+	{
+		name:     "rate limited",
+		scenario: Scenario{RateLimited: 1000, RetryAfter: 30 * time.Second},
+		check: func(r provider.Result) []string {
+			problems := checkShort(r, "?")
+			var limited *provider.RateLimitedError
+			if r.Error != nil && !errors.As(r.Error, &limited) {
+				problems = append(problems, fmt.Sprintf("error %q does not wrap *provider.RateLimitedError", r.Error))
+			}
+			return problems
+		},
+	},
+	{
+		name:     "malformed response",
+		scenario: Scenario{MalformedJSON: true},
+		check: func(r provider.Result) []string {
+			return checkShort(r, "?")
+		},
+	},
+	{
+		name:     "cancelled context",
+		scenario: Scenario{Delay: time.Minute},
+		fetch: func(p provider.Provider) provider.Result {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			return p.Fetch(ctx)
+		},
+		check: func(r provider.Result) []string {
+			return checkContextError(r, context.Canceled)
+		},
+	},
+	{
+		name:     "timeout",
+		scenario: Scenario{Delay: time.Minute},
+		fetch: func(p provider.Provider) provider.Result {
+			ctx, cancel := context.WithTimeout(context.Background(), conformanceTimeout)
+			defer cancel()
+			return p.Fetch(ctx)
+		},
+		check: func(r provider.Result) []string {
+			return checkContextError(r, context.DeadlineExceeded)
+		},
+	},
+}
+
+// RunConformance checks c.Provider against the conventions every provider
+// shares, with a fresh fake per case:
+//
+//   - a healthy account gives a Short and a Class no milder than the first
+//     window implies;
+//   - an expired OAuth token is refreshed, and rejected credentials give
+//     Short "!";
+//   - a lasting 429 surfaces as a *provider.RateLimitedError, and
+//     unreadable responses as errors, both with Short "?";
+//   - a cancelled or expired context returns promptly with its error;
+//   - Result.Name is the provider's name, and no error contains a
+//     credential.
+func RunConformance(t *testing.T, c Conformance) {
+	t.Helper()
+	for _, tc := range conformanceCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := c.Fake(t)
+			fake.Set(tc.scenario)
+			fake.Install(t)
+			secrets := append(credentials(fake), c.Secrets...)
+
+			fetch := tc.fetch
+			if fetch == nil {
+				fetch = func(p provider.Provider) provider.Result {
+					return provider.FetchAll(context.Background(), []provider.Provider{p})[0]
+				}
+			}
+			start := time.Now()
+			r := fetch(c.Provider)
+			elapsed := time.Since(start)
+
+			problems := tc.check(r)
+			problems = append(problems, checkResult(c.Provider.Name(), r, append(secrets, credentials(fake)...))...)
+			if tc.fetch != nil && elapsed > conformanceTimeout+2*time.Second {
+				problems = append(problems, fmt.Sprintf("took %v to give up", elapsed.Round(time.Millisecond)))
+			}
+			for _, p := range problems {
+				t.Error(p)
+			}
+		})
+	}
+}
+
+func credentials(f Fake) []string {
+	access, refresh := f.Tokens()
+	return []string{access, refresh}
+}
+
+// checkResult applies the checks that hold for every result.
+func checkResult(name string, r provider.Result, secrets []string) []string {
+	var problems []string
+	if r.Name != name {
+		problems = append(problems, fmt.Sprintf("Result.Name is %q, want %q", r.Name, name))
+	}
+	if r.Error == nil {
+		return problems
+	}
+	if r.Short != "?" && r.Short != "!" {
+		problems = append(problems, fmt.Sprintf("failed result has Short %q, want \"?\" or \"!\"", r.Short))
+	}
+	msg := r.Error.Error()
+	for _, s := range secrets {
+		if s != "" && strings.Contains(msg, s) {
+			problems = append(problems, fmt.Sprintf("error %q contains a credential", msg))
+			break
+		}
+	}
+	return problems
+}
+
+func checkShort(r provider.Result, want string) []string {
+	if r.Error == nil {
+		return []string{fmt.Sprintf("expected an error with Short %q, got success %+v", want, r)}
+	}
+	if r.Short != want {
+		return []string{fmt.Sprintf("error %q has Short %q, want %q", r.Error, r.Short, want)}
+	}
+	return nil
+}
+
+func checkHealthy(r provider.Result) []string {
+	if r.Error != nil {
+		return []string{fmt.Sprintf("unexpected error: %v", r.Error)}
+	}
+	var problems []string
+	if r.Short == "" || r.Short == "?" || r.Short == "!" {
+		problems = append(problems, fmt.Sprintf("successful result has Short %q", r.Short))
+	}
+	if _, ok := classRanks[r.Class]; !ok {
+		problems = append(problems, fmt.Sprintf("unknown Class %q", r.Class))
+	}
+	if len(r.Windows) > 0 {
+		if want := windowClass(r.Windows[0].UsedPct); classRanks[r.Class] < classRanks[want] {
+			problems = append(problems, fmt.Sprintf("Class %q is milder than %q for the primary window at %.0f%%", r.Class, want, r.Windows[0].UsedPct))
+		}
+	}
+	return problems
+}
+
+func checkContextError(r provider.Result, want error) []string {
+	problems := checkShort(r, "?")
+	if r.Error != nil && !errors.Is(r.Error, want) {
+		problems = append(problems, fmt.Sprintf("error %q does not wrap %v", r.Error, want))
+	}
+	return problems
+}
+
+// classRanks orders the classes Waybar styles; "" is treated as normal.
+var classRanks = map[string]int{"": 0, "normal": 0, "warning": 1, "critical": 2}
+
+// windowClass is the class a window's usage implies, with the thresholds
+// the built-in providers use.
+func windowClass(pct float64) string {
+	switch {
+	case pct >= 90:
+		return "critical"
+	case pct >= 75:
+		return "warning"
+	default:
+		return "normal"
+	}
+}
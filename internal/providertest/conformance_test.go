@@ -0,0 +1,53 @@
+package providertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jhartzell/ai-usage-bar/internal/provider"
+)
+
+func TestCheckResultCatchesLeaksAndBadShorts(t *testing.T) {
+	leaky := provider.Result{
+		Name:  "Gateway",
+		Short: "err",
+		Error: errors.New(`HTTP 401: {"message":"invalid token fake-access-token-1"}`),
+	}
+	problems := checkResult("Gateway", leaky, []string{"fake-access-token-1"})
+	if len(problems) != 2 || !strings.Contains(problems[0], "Short") || !strings.Contains(problems[1], "credential") {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+
+	if problems := checkResult("Gateway", provider.Result{Name: "gateway"}, nil); len(problems) != 1 {
+		t.Fatalf("expected a name mismatch, got %v", problems)
+	}
+}
+
+func TestCheckHealthyClassConsistency(t *testing.T) {
+	r := provider.Result{Name: "Gateway", Short: "95%", Class: "normal", Windows: []provider.RateWindow{{UsedPct: 95}}}
+	if problems := checkHealthy(r); len(problems) != 1 {
+		t.Fatalf("expected a class problem, got %v", problems)
+	}
+
+	// A worse class than the window implies is fine, e.g. a low balance.
+	r.Class = "critical"
+	r.Windows[0].UsedPct = 10
+	if problems := checkHealthy(r); len(problems) != 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+}
+
+func TestCheckContextError(t *testing.T) {
+	wrapped := provider.Result{Short: "?", Error: fmt.Errorf("usage: %w", context.DeadlineExceeded)}
+	if problems := checkContextError(wrapped, context.DeadlineExceeded); len(problems) != 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+
+	flattened := provider.Result{Short: "?", Error: fmt.Errorf("usage: %v", context.DeadlineExceeded)}
+	if problems := checkContextError(flattened, context.DeadlineExceeded); len(problems) != 1 {
+		t.Fatalf("expected the lost context error to be reported, got %v", problems)
+	}
+}
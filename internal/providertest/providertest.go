@@ -0,0 +1,305 @@
+// Package providertest runs fake vendor APIs for end-to-end provider
+// tests. Each server emulates the endpoints one built-in provider calls,
+// checks credentials the way the vendor does, and can be switched into a
+// Scenario such as an expired token or a rate limit mid-test.
+//
+// Install points the provider at the server through its endpoint override
+// variables and writes matching credentials below a temporary HOME, so the
+// provider runs unmodified:
+//
+//	srv := providertest.NewClaude(t)
+//	srv.Set(providertest.Scenario{ExpiredToken: true})
+//	srv.Install(t)
+//	r := provider.Claude{}.Fetch(ctx)
+package providertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Scenario scripts how a server misbehaves. The zero value is a healthy
+// account.
+type Scenario struct {
+	// ExpiredToken rejects the current access token with 401 until the
+	// client refreshes it.
+	ExpiredToken bool
+	// RotateTokens issues a new refresh token on every refresh and revokes
+	// the old one, as OAuth servers with rotation do.
+	RotateTokens bool
+	// Revoked rejects both the access and the refresh token, as after the
+	// user signs out everywhere.
+	Revoked bool
+	// RateLimited answers this many authenticated requests with 429
+	// before serving them again, sending RetryAfter when it is set.
+	RateLimited int
+	RetryAfter  time.Duration
+	// MalformedJSON truncates every response body but the token
+	// endpoint's.
+	MalformedJSON bool
+	// Delay holds every response back this long, or until the client
+	// gives up.
+	Delay time.Duration
+}
+
+// Server is a fake vendor API.
+type Server struct {
+	*httptest.Server
+
+	tokenPath string
+	install   func(t testing.TB, s *Server)
+
+	mu         sync.Mutex
+	scenario   Scenario
+	access     string
+	refresh    string
+	expired    bool
+	generation int
+	limited    int
+	bodies     map[string]string
+	requests   []string
+}
+
+func newServer(t testing.TB, tokenPath string, bodies map[string]string, install func(testing.TB, *Server)) *Server {
+	t.Helper()
+	s := &Server{
+		tokenPath:  tokenPath,
+		install:    install,
+		access:     "fake-access-token-1",
+		refresh:    "fake-refresh-token-1",
+		generation: 1,
+		bodies:     bodies,
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Install points the provider at s and writes credentials holding the
+// server's current tokens below a fresh HOME. Call it after Set when the
+// scenario affects the starting tokens.
+func (s *Server) Install(t testing.TB) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	s.install(t, s)
+}
+
+// Set switches the server to sc.
+func (s *Server) Set(sc Scenario) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scenario = sc
+	s.expired = sc.ExpiredToken
+	s.limited = 0
+}
+
+// SetBody replaces the JSON served for path, e.g. to script a usage
+// level.
+func (s *Server) SetBody(path, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bodies[path] = body
+}
+
+// Tokens returns the access and refresh tokens the server accepts now.
+func (s *Server) Tokens() (access, refresh string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.access, s.refresh
+}
+
+// Requests lists the requests served so far as "METHOD /path".
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// Count returns how many requests were made to path.
+func (s *Server) Count(path string) int {
+	n := 0
+	for _, r := range s.Requests() {
+		if strings.HasSuffix(r, " "+path) {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *Server) serve(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, req.Method+" "+req.URL.Path)
+	delay := s.scenario.Delay
+	s.mu.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return
+		}
+	}
+
+	if req.URL.Path == s.tokenPath && s.tokenPath != "" {
+		s.serveToken(w, req)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	body, ok := s.bodies[req.URL.Path]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, `{"error":"not_found"}`)
+		return
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer "+s.access || s.expired || s.scenario.Revoked {
+		// Echo the credential, as some vendors do, so a provider that puts
+		// response bodies into its errors leaks it visibly.
+		msg, _ := json.Marshal("invalid or expired token: " + strings.TrimPrefix(got, "Bearer "))
+		writeJSON(w, http.StatusUnauthorized, `{"error":{"type":"authentication_error","message":`+string(msg)+`}}`)
+		return
+	}
+	if s.limited < s.scenario.RateLimited {
+		s.limited++
+		if s.scenario.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(s.scenario.RetryAfter.Seconds())))
+		}
+		writeJSON(w, http.StatusTooManyRequests, `{"error":{"type":"rate_limit_error"}}`)
+		return
+	}
+	if s.scenario.MalformedJSON {
+		body = body[:len(body)/2]
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (s *Server) serveToken(w http.ResponseWriter, req *http.Request) {
+	data, _ := io.ReadAll(req.Body)
+	form, _ := url.ParseQuery(string(data))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if form.Get("grant_type") != "refresh_token" || form.Get("refresh_token") != s.refresh || s.scenario.Revoked {
+		msg, _ := json.Marshal("refresh token " + form.Get("refresh_token") + " is invalid")
+		writeJSON(w, http.StatusBadRequest, `{"error":"invalid_grant","error_description":`+string(msg)+`}`)
+		return
+	}
+
+	s.generation++
+	s.access = fmt.Sprintf("fake-access-token-%d", s.generation)
+	s.expired = false
+	resp := map[string]any{
+		"access_token": s.access,
+		"expires_in":   3600,
+		"token_type":   "Bearer",
+	}
+	if s.scenario.RotateTokens {
+		s.refresh = fmt.Sprintf("fake-refresh-token-%d", s.generation)
+		resp["refresh_token"] = s.refresh
+	}
+	out, _ := json.Marshal(resp)
+	writeJSON(w, http.StatusOK, string(out))
+}
+
+func writeJSON(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	io.WriteString(w, body)
+}
+
+func writeFile(t testing.TB, rel string, v any) {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(os.Getenv("HOME"), rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Default response bodies.
+const (
+	ClaudeUsage       = `{"five_hour":{"utilization":42,"resets_at":"2026-10-18T21:00:00Z"},"seven_day":{"utilization":17,"resets_at":"2026-10-23T09:00:00Z"}}`
+	ClaudeProfile     = `{"account":{"email":"user@example.com","display_name":"Test User"}}`
+	CodexUsage        = `{"email":"user@example.com","plan_type":"plus","rate_limit":{"allowed":true,"limit_reached":false,"primary_window":{"used_percent":35,"limit_window_seconds":18000,"reset_at":1792364400},"secondary_window":{"used_percent":12,"limit_window_seconds":604800,"reset_at":1792760000}}}`
+	OpenRouterKey     = `{"data":{"label":"ci","limit":100,"limit_remaining":64.5,"usage":35.5,"usage_daily":1.5,"usage_weekly":8,"usage_monthly":30,"is_free_tier":false}}`
+	OpenRouterCredits = `{"data":{"total_credits":100,"total_usage":35.5}}`
+)
+
+// Paths the fakes serve.
+const (
+	ClaudeUsagePath       = "/api/oauth/usage"
+	ClaudeProfilePath     = "/api/oauth/profile"
+	ClaudeTokenPath       = "/v1/oauth/token"
+	CodexUsagePath        = "/backend-api/wham/usage"
+	CodexTokenPath        = "/oauth/token"
+	OpenRouterKeyPath     = "/api/v1/key"
+	OpenRouterCreditsPath = "/api/v1/credits"
+)
+
+// NewClaude fakes the Claude OAuth usage, profile and token endpoints.
+// Install writes ~/.claude/.credentials.json.
+func NewClaude(t testing.TB) *Server {
+	t.Helper()
+	bodies := map[string]string{ClaudeUsagePath: ClaudeUsage, ClaudeProfilePath: ClaudeProfile}
+	return newServer(t, ClaudeTokenPath, bodies, func(t testing.TB, s *Server) {
+		t.Setenv("AI_USAGE_BAR_CLAUDE_URL", s.URL+"/api/oauth")
+		t.Setenv("AI_USAGE_BAR_CLAUDE_TOKEN_URL", s.URL+ClaudeTokenPath)
+		access, refresh := s.Tokens()
+		writeFile(t, filepath.Join(".claude", ".credentials.json"), map[string]any{
+			"claudeAiOauth": map[string]any{
+				"accessToken":      access,
+				"refreshToken":     refresh,
+				"expiresAt":        time.Now().Add(time.Hour).UnixMilli(),
+				"subscriptionType": "pro",
+			},
+		})
+	})
+}
+
+// NewCodex fakes the ChatGPT usage and OpenAI token endpoints. Install
+// writes ~/.codex/auth.json.
+func NewCodex(t testing.TB) *Server {
+	t.Helper()
+	bodies := map[string]string{CodexUsagePath: CodexUsage}
+	return newServer(t, CodexTokenPath, bodies, func(t testing.TB, s *Server) {
+		t.Setenv("AI_USAGE_BAR_CODEX_URL", s.URL+"/backend-api")
+		t.Setenv("AI_USAGE_BAR_CODEX_TOKEN_URL", s.URL+CodexTokenPath)
+		access, refresh := s.Tokens()
+		writeFile(t, filepath.Join(".codex", "auth.json"), map[string]any{
+			"tokens": map[string]any{"access_token": access, "refresh_token": refresh},
+		})
+	})
+}
+
+// NewOpenRouter fakes the OpenRouter key and credits endpoints. API keys
+// can't be refreshed, so an expired token stays rejected. Install sets
+// OPENROUTER_API_KEY.
+func NewOpenRouter(t testing.TB) *Server {
+	t.Helper()
+	bodies := map[string]string{OpenRouterKeyPath: OpenRouterKey, OpenRouterCreditsPath: OpenRouterCredits}
+	return newServer(t, "", bodies, func(t testing.TB, s *Server) {
+		t.Setenv("AI_USAGE_BAR_OPENROUTER_URL", s.URL+"/api/v1")
+		access, _ := s.Tokens()
+		t.Setenv("OPENROUTER_API_KEY", access)
+		t.Setenv("OPENROUTER_PROVISIONING_KEY", "")
+	})
+}
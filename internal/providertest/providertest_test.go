@@ -0,0 +1,179 @@
+package providertest_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jhartzell/ai-usage-bar/internal/provider"
+	"github.com/jhartzell/ai-usage-bar/internal/providertest"
+)
+
+func claudeTokens(t *testing.T) (access, refresh string) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(os.Getenv("HOME"), ".claude", ".credentials.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var creds struct {
+		ClaudeAiOauth struct {
+			AccessToken  string `json:"accessToken"`
+			RefreshToken string `json:"refreshToken"`
+		} `json:"claudeAiOauth"`
+	}
+	if err := json.Unmarshal(data, &creds); err != nil {
+		t.Fatal(err)
+	}
+	return creds.ClaudeAiOauth.AccessToken, creds.ClaudeAiOauth.RefreshToken
+}
+
+func TestClaudeHealthy(t *testing.T) {
+	srv := providertest.NewClaude(t)
+	srv.Install(t)
+
+	r := provider.Claude{}.Fetch(context.Background())
+	if r.Error != nil {
+		t.Fatalf("unexpected error: %v", r.Error)
+	}
+	if r.Short != "42%" || r.Identity != "user@example.com" || len(r.Windows) != 2 {
+		t.Fatalf("unexpected result: %+v", r)
+	}
+	if srv.Count(providertest.ClaudeTokenPath) != 0 {
+		t.Fatalf("unexpected refresh: %v", srv.Requests())
+	}
+}
+
+func TestClaudeExpiredTokenIsRefreshedAndSaved(t *testing.T) {
+	srv := providertest.NewClaude(t)
+	srv.Set(providertest.Scenario{ExpiredToken: true})
+	srv.Install(t)
+
+	r := provider.Claude{}.Fetch(context.Background())
+	if r.Error != nil || r.Short != "42%" {
+		t.Fatalf("expected success after refresh, got %+v", r)
+	}
+	if srv.Count(providertest.ClaudeTokenPath) != 1 {
+		t.Fatalf("expected one refresh, got %v", srv.Requests())
+	}
+	wantAccess, _ := srv.Tokens()
+	if access, refresh := claudeTokens(t); access != wantAccess || refresh != "fake-refresh-token-1" {
+		t.Fatalf("unexpected saved tokens %q, %q", access, refresh)
+	}
+}
+
+func TestClaudeRotatedRefreshTokenIsKept(t *testing.T) {
+	srv := providertest.NewClaude(t)
+	srv.Set(providertest.Scenario{ExpiredToken: true, RotateTokens: true})
+	srv.Install(t)
+
+	// The second refresh only works if the first one's rotated refresh
+	// token was saved.
+	for i := range 2 {
+		srv.Set(providertest.Scenario{ExpiredToken: true, RotateTokens: true})
+		if r := (provider.Claude{}).Fetch(context.Background()); r.Error != nil {
+			t.Fatalf("fetch %d: %v", i+1, r.Error)
+		}
+	}
+	access, refresh := srv.Tokens()
+	if gotAccess, gotRefresh := claudeTokens(t); gotAccess != access || gotRefresh != refresh || refresh != "fake-refresh-token-3" {
+		t.Fatalf("saved %q, %q; server expects %q, %q", gotAccess, gotRefresh, access, refresh)
+	}
+}
+
+func TestCodexExpiredTokenWithRotation(t *testing.T) {
+	srv := providertest.NewCodex(t)
+	srv.Set(providertest.Scenario{ExpiredToken: true, RotateTokens: true})
+	srv.Install(t)
+
+	r := provider.Codex{}.Fetch(context.Background())
+	if r.Error != nil || r.Short != "35%" {
+		t.Fatalf("expected success after refresh, got %+v", r)
+	}
+	data, err := os.ReadFile(filepath.Join(os.Getenv("HOME"), ".codex", "auth.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"refresh_token":"fake-refresh-token-2"`) {
+		t.Fatalf("rotated refresh token not saved: %s", data)
+	}
+}
+
+func TestRevokedAPIKey(t *testing.T) {
+	srv := providertest.NewOpenRouter(t)
+	srv.Set(providertest.Scenario{ExpiredToken: true})
+	srv.Install(t)
+
+	r := provider.OpenRouter{}.Fetch(context.Background())
+	if r.Error == nil || r.Short != "!" {
+		t.Fatalf("expected an auth failure, got %+v", r)
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	srv := providertest.NewOpenRouter(t)
+	srv.Set(providertest.Scenario{RateLimited: 5, RetryAfter: 30 * time.Second})
+	srv.Install(t)
+
+	// FetchAll's deadline leaves no room to wait 30s.
+	r := provider.FetchAll(context.Background(), []provider.Provider{provider.OpenRouter{}})[0]
+	if r.Error == nil || r.Error.Error() != "rate limited, retry in 30s" || r.Short != "?" {
+		t.Fatalf("expected a rate-limit error, got %+v", r)
+	}
+
+	srv.Set(providertest.Scenario{RateLimited: 1})
+	r = provider.FetchAll(context.Background(), []provider.Provider{provider.OpenRouter{}})[0]
+	if r.Error != nil || r.Short != "$64.50" {
+		t.Fatalf("expected success after a retry, got %+v", r)
+	}
+}
+
+func TestMalformedJSON(t *testing.T) {
+	srv := providertest.NewClaude(t)
+	srv.Set(providertest.Scenario{MalformedJSON: true})
+	srv.Install(t)
+
+	r := provider.Claude{}.Fetch(context.Background())
+	if r.Error == nil || r.Short != "?" {
+		t.Fatalf("expected a decode error, got %+v", r)
+	}
+}
+
+func TestSlowResponse(t *testing.T) {
+	srv := providertest.NewCodex(t)
+	srv.Set(providertest.Scenario{Delay: 10 * time.Second})
+	srv.Install(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	r := provider.Codex{}.Fetch(ctx)
+	if r.Error == nil {
+		t.Fatalf("expected a timeout, got %+v", r)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("fetch ignored its deadline, took %v", elapsed)
+	}
+}
+
+func TestBuiltInProvidersConform(t *testing.T) {
+	tests := []struct {
+		p    provider.Provider
+		fake func(testing.TB) *providertest.Server
+	}{
+		{p: provider.Claude{}, fake: providertest.NewClaude},
+		{p: provider.Codex{}, fake: providertest.NewCodex},
+		{p: provider.OpenRouter{}, fake: providertest.NewOpenRouter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.p.Name(), func(t *testing.T) {
+			providertest.RunConformance(t, providertest.Conformance{
+				Provider: tt.p,
+				Fake:     func(t testing.TB) providertest.Fake { return tt.fake(t) },
+			})
+		})
+	}
+}
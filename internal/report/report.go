@@ -0,0 +1,167 @@
+package report
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jhartzell/ai-usage-bar/internal/provider"
+)
+
+// Result is the machine-readable form of a provider.Result printed by --json.
+type Result struct {
+	Name      string      `json:"name"`
+	Identity  string      `json:"identity,omitempty"`
+	Short     string      `json:"short,omitempty"`
+	Class     string      `json:"class,omitempty"`
+	Plan      string      `json:"plan,omitempty"`
+	Windows   []Window    `json:"windows,omitempty"`
+	Spend     []Spend     `json:"spend,omitempty"`
+	Keys      []Key       `json:"keys,omitempty"`
+	Breakdown []Breakdown `json:"breakdown,omitempty"`
+	Credits   *float64    `json:"credits,omitempty"`
+	Currency  string      `json:"currency,omitempty"`
+	Tokens    []Tokens    `json:"tokens,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	Source    string      `json:"source,omitempty"`
+	AsOf      *time.Time  `json:"as_of,omitempty"`
+}
+
+type Window struct {
+	Label    string     `json:"label"`
+	UsedPct  float64    `json:"used_pct"`
+	ResetsAt *time.Time `json:"resets_at,omitempty"`
+}
+
+type Spend struct {
+	Label    string  `json:"label"`
+	Amount   float64 `json:"amount"`
+	Estimate bool    `json:"estimate,omitempty"`
+}
+
+type Key struct {
+	Label     string   `json:"label"`
+	Limit     *float64 `json:"limit,omitempty"`
+	Remaining *float64 `json:"remaining,omitempty"`
+	Daily     float64  `json:"daily"`
+	Weekly    float64  `json:"weekly"`
+	Monthly   float64  `json:"monthly"`
+	Disabled  bool     `json:"disabled,omitempty"`
+	Class     string   `json:"class,omitempty"`
+}
+
+type Breakdown struct {
+	Group    string  `json:"group"`
+	Label    string  `json:"label"`
+	Amount   float64 `json:"amount"`
+	Requests int     `json:"requests,omitempty"`
+	Tokens   int64   `json:"tokens,omitempty"`
+}
+
+// Tokens is locally counted token usage for one period.
+type Tokens struct {
+	Label string `json:"label"`
+	TokenCount
+	Models   []TokenRow `json:"models,omitempty"`
+	Projects []TokenRow `json:"projects,omitempty"`
+}
+
+type TokenRow struct {
+	Label string `json:"label"`
+	TokenCount
+}
+
+type TokenCount struct {
+	Input      int64 `json:"input"`
+	Output     int64 `json:"output"`
+	CacheWrite int64 `json:"cache_write"`
+	CacheRead  int64 `json:"cache_read"`
+	Total      int64 `json:"total"`
+}
+
+// FromResults converts provider results to their JSON report form.
+func FromResults(results []provider.Result) []Result {
+	out := make([]Result, 0, len(results))
+	for _, r := range results {
+		rr := Result{
+			Name:     r.Name,
+			Identity: r.Identity,
+			Short:    r.Short,
+			Class:    r.Class,
+			Plan:     r.Plan,
+			Credits:  r.Credits,
+			Currency: r.Currency,
+		}
+		if r.Error != nil {
+			rr.Error = r.Error.Error()
+		}
+		if r.Source != "" {
+			rr.Source = r.Source
+			if !r.AsOf.IsZero() {
+				asOf := r.AsOf.UTC()
+				rr.AsOf = &asOf
+			}
+		}
+
+		for _, w := range r.Windows {
+			win := Window{Label: w.Label, UsedPct: w.UsedPct}
+			if w.HasReset {
+				reset := w.ResetAt.UTC()
+				win.ResetsAt = &reset
+			}
+			rr.Windows = append(rr.Windows, win)
+		}
+		for _, s := range r.Spend {
+			rr.Spend = append(rr.Spend, Spend{Label: s.Label, Amount: s.Amount, Estimate: s.Estimate})
+		}
+		for _, k := range r.Keys {
+			rr.Keys = append(rr.Keys, Key{
+				Label:     k.Label,
+				Limit:     k.Limit,
+				Remaining: k.Remaining,
+				Daily:     k.Daily,
+				Weekly:    k.Weekly,
+				Monthly:   k.Monthly,
+				Disabled:  k.Disabled,
+				Class:     k.Class,
+			})
+		}
+		for _, b := range r.Breakdown {
+			rr.Breakdown = append(rr.Breakdown, Breakdown{
+				Group:    b.Group,
+				Label:    b.Label,
+				Amount:   b.Amount,
+				Requests: b.Requests,
+				Tokens:   b.Tokens,
+			})
+		}
+		for _, t := range r.Tokens {
+			rt := Tokens{Label: t.Label, TokenCount: tokenCount(t.TokenCount)}
+			for _, m := range t.Models {
+				rt.Models = append(rt.Models, TokenRow{Label: m.Label, TokenCount: tokenCount(m.TokenCount)})
+			}
+			for _, p := range t.Projects {
+				rt.Projects = append(rt.Projects, TokenRow{Label: p.Label, TokenCount: tokenCount(p.TokenCount)})
+			}
+			rr.Tokens = append(rr.Tokens, rt)
+		}
+
+		out = append(out, rr)
+	}
+	return out
+}
+
+func tokenCount(t provider.TokenCount) TokenCount {
+	return TokenCount{
+		Input:      t.Input,
+		Output:     t.Output,
+		CacheWrite: t.CacheWrite,
+		CacheRead:  t.CacheRead,
+		Total:      t.Total(),
+	}
+}
+
+// JSON renders results as indented JSON for scripting and debugging.
+func JSON(results []provider.Result) string {
+	b, _ := json.MarshalIndent(FromResults(results), "", "  ")
+	return string(b)
+}
@@ -0,0 +1,82 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jhartzell/ai-usage-bar/internal/provider"
+)
+
+func TestJSONIncludesBreakdownAndErrors(t *testing.T) {
+	results := []provider.Result{
+		{
+			Name:  "OpenRouter",
+			Short: "$12.00",
+			Windows: []provider.RateWindow{
+				{Label: "Budget", UsedPct: 40},
+				{Label: "Session (5h)", UsedPct: 10, HasReset: true, ResetAt: time.Unix(1_700_000_000, 0)},
+			},
+			Breakdown: []provider.BreakdownEntry{
+				{Group: "Top models (30d)", Label: "anthropic/claude-opus-4", Amount: 9.5, Requests: 12},
+			},
+		},
+		{Name: "Codex", Short: "?", Error: errors.New("boom")},
+	}
+
+	var parsed []map[string]any
+	if err := json.Unmarshal([]byte(JSON(results)), &parsed); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	if len(parsed) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(parsed))
+	}
+
+	windows := parsed[0]["windows"].([]any)
+	if _, ok := windows[0].(map[string]any)["resets_at"]; ok {
+		t.Fatal("expected window without reset to omit resets_at")
+	}
+	if windows[1].(map[string]any)["resets_at"] != "2023-11-14T22:13:20Z" {
+		t.Fatalf("unexpected resets_at: %#v", windows[1])
+	}
+
+	breakdown := parsed[0]["breakdown"].([]any)
+	row := breakdown[0].(map[string]any)
+	if row["label"] != "anthropic/claude-opus-4" || row["amount"] != 9.5 || row["requests"] != float64(12) {
+		t.Fatalf("unexpected breakdown row: %#v", row)
+	}
+
+	if parsed[1]["error"] != "boom" {
+		t.Fatalf("expected error string, got %#v", parsed[1]["error"])
+	}
+}
+
+func TestJSONIncludesTokensForErroredResult(t *testing.T) {
+	results := []provider.Result{{
+		Name:  "Claude",
+		Short: "?",
+		Error: errors.New("offline"),
+		Tokens: []provider.TokenPeriod{{
+			Label:      "Today",
+			TokenCount: provider.TokenCount{Input: 10, Output: 5, CacheRead: 100},
+			Models:     []provider.TokenRow{{Label: "claude-sonnet", TokenCount: provider.TokenCount{Output: 5}}},
+		}},
+	}}
+
+	var parsed []map[string]any
+	if err := json.Unmarshal([]byte(JSON(results)), &parsed); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	tokens := parsed[0]["tokens"].([]any)
+	today := tokens[0].(map[string]any)
+	if today["label"] != "Today" || today["total"] != float64(115) || today["cache_read"] != float64(100) {
+		t.Fatalf("unexpected token period: %#v", today)
+	}
+	model := today["models"].([]any)[0].(map[string]any)
+	if model["label"] != "claude-sonnet" || model["output"] != float64(5) {
+		t.Fatalf("unexpected model row: %#v", model)
+	}
+}
@@ -0,0 +1,361 @@
+// Package tape records provider HTTP exchanges to a directory of JSON
+// fixtures and plays them back, so a failure seen on one machine can be
+// reproduced offline and kept as a regression test.
+//
+// Fixtures are sanitized as they are written: credential headers are
+// replaced, bodies go through diag.Redact, identity fields in JSON bodies
+// and query strings are blanked and email addresses are swapped for a
+// placeholder, so a tape can be shared without leaking the account.
+package tape
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jhartzell/ai-usage-bar/internal/diag"
+)
+
+// Exchange is one recorded request and its response, stored as one file.
+type Exchange struct {
+	Provider string   `json:"provider,omitempty"`
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string            `json:"method"`
+	URL    string            `json:"url"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   string            `json:"body,omitempty"`
+}
+
+type Response struct {
+	Status int               `json:"status"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   string            `json:"body,omitempty"`
+}
+
+const (
+	redacted         = "[REDACTED]"
+	placeholderEmail = "user@example.com"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// identityParam matches query parameters that carry an account id,
+	// such as Cursor's /api/usage?user=<id>.
+	identityParam = regexp.MustCompile(`([?&](?:user|user_id|account_id)=)[^&#]*`)
+)
+
+// sensitiveHeader reports whether a header carries credentials or session
+// state.
+func sensitiveHeader(name string) bool {
+	name = strings.ToLower(name)
+	switch name {
+	case "authorization", "proxy-authorization", "cookie", "set-cookie":
+		return true
+	}
+	for _, s := range []string{"api-key", "apikey", "secret", "session", "password"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	// Not just "token": rate-limit headers such as
+	// anthropic-ratelimit-tokens-remaining are what a tape is for.
+	return strings.HasSuffix(name, "-token") || strings.HasSuffix(name, "-key")
+}
+
+func sanitizeHeader(h http.Header) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(h))
+	for name, values := range h {
+		if name == "Content-Length" {
+			continue // sanitizing can change the body's length
+		}
+		v := strings.Join(values, ", ")
+		if sensitiveHeader(name) {
+			v = redacted
+		}
+		out[name] = sanitize(v)
+	}
+	return out
+}
+
+func sanitize(s string) string {
+	return emailPattern.ReplaceAllString(diag.Redact(s), placeholderEmail)
+}
+
+// sanitizeURL is sanitize plus blanking identity query parameters. The
+// Player applies it to live requests too, so they match whatever id was
+// recorded.
+func sanitizeURL(s string) string {
+	return identityParam.ReplaceAllString(sanitize(s), "${1}"+redacted)
+}
+
+// sanitizeBody is sanitize plus, for JSON, blanking fields that name or
+// identify the account.
+func sanitizeBody(s string) string {
+	var v any
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	if dec.Decode(&v) != nil || dec.More() || !redactIdentity(v, "") {
+		return sanitize(s)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if enc.Encode(v) != nil {
+		return sanitize(s)
+	}
+	return sanitize(strings.TrimSuffix(buf.String(), "\n"))
+}
+
+// identityKey reports whether a JSON field under parent names or
+// identifies a person or organization.
+func identityKey(parent, key string) bool {
+	key = strings.ToLower(key)
+	switch key {
+	case "display_name", "full_name", "login", "account_id", "user_id":
+		return true
+	case "name":
+		parent = strings.ToLower(parent)
+		return parent == "account" || parent == "organization" || parent == "user"
+	}
+	return strings.HasSuffix(key, "uuid")
+}
+
+// redactIdentity replaces identity fields in a decoded JSON value in place
+// and reports whether it changed anything.
+func redactIdentity(v any, parent string) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if _, ok := child.(string); ok && identityKey(parent, k) {
+				v[k] = redacted
+				changed = true
+				continue
+			}
+			changed = redactIdentity(child, k) || changed
+		}
+	case []any:
+		for _, child := range v {
+			changed = redactIdentity(child, parent) || changed
+		}
+	}
+	return changed
+}
+
+// Recorder writes each exchange made through its transports to Dir as
+// NNN-<provider>-<method>-<path>.json, numbered in the order the
+// responses arrive.
+type Recorder struct {
+	Dir string
+	// Name returns the provider a request was made for, used in the file
+	// name; optional.
+	Name func(*http.Request) string
+
+	mu  sync.Mutex
+	seq int
+}
+
+// NewRecorder creates dir and returns a Recorder writing to it.
+func NewRecorder(dir string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &Recorder{Dir: dir}, nil
+}
+
+// Transport returns an http.RoundTripper that sends requests through next
+// (http.DefaultTransport when nil) and records them.
+func (r *Recorder) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return recording{r: r, next: next}
+}
+
+type recording struct {
+	r    *Recorder
+	next http.RoundTripper
+}
+
+func (rt recording) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = io.ReadAll(body)
+			body.Close()
+		}
+	}
+
+	resp, err := rt.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	ex := Exchange{
+		Request: Request{
+			Method: req.Method,
+			URL:    sanitizeURL(req.URL.String()),
+			Header: sanitizeHeader(req.Header),
+			Body:   sanitizeBody(string(reqBody)),
+		},
+		Response: Response{
+			Status: resp.StatusCode,
+			Header: sanitizeHeader(resp.Header),
+			Body:   sanitizeBody(string(respBody)),
+		},
+	}
+	if rt.r.Name != nil {
+		ex.Provider = rt.r.Name(req)
+	}
+	if err := rt.r.write(ex); err != nil {
+		diag.Logf("record: %v", err)
+	}
+	return resp, nil
+}
+
+func (r *Recorder) write(ex Exchange) error {
+	data, err := json.MarshalIndent(ex, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.seq++
+	seq := r.seq
+	r.mu.Unlock()
+
+	name := fmt.Sprintf("%03d-%s.json", seq, slug(ex))
+	return os.WriteFile(filepath.Join(r.Dir, name), append(data, '\n'), 0o600)
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+func slug(ex Exchange) string {
+	path := ex.Request.URL
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	path = path[strings.LastIndex(path, "/")+1:]
+	parts := []string{ex.Provider, ex.Request.Method, path}
+	s := nonSlug.ReplaceAllString(strings.ToLower(strings.Join(parts, "-")), "-")
+	return strings.Trim(s, "-")
+}
+
+// Player is an http.RoundTripper serving recorded responses. Requests are
+// matched by method and URL; repeated requests get the recorded responses
+// in order, the last one repeating once they run out.
+type Player struct {
+	mu        sync.Mutex
+	queues    map[string][]Response
+	current   map[string]int
+	providers []string
+}
+
+// Load reads every fixture in dir.
+func Load(dir string) (*Player, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no recorded exchanges in %s", dir)
+	}
+	sort.Strings(files)
+
+	p := &Player{queues: map[string][]Response{}, current: map[string]int{}}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var ex Exchange
+		if err := json.Unmarshal(data, &ex); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(f), err)
+		}
+		if ex.Request.Method == "" || ex.Request.URL == "" || ex.Response.Status == 0 {
+			return nil, fmt.Errorf("%s: missing method, URL or status", filepath.Base(f))
+		}
+		key := matchKey(ex.Request.Method, ex.Request.URL)
+		p.queues[key] = append(p.queues[key], ex.Response)
+		if ex.Provider != "" && !slices.Contains(p.providers, ex.Provider) {
+			p.providers = append(p.providers, ex.Provider)
+		}
+	}
+	return p, nil
+}
+
+// Providers lists the providers the tape has exchanges for, in the order
+// they first appear.
+func (p *Player) Providers() []string {
+	return p.providers
+}
+
+// ErrNotRecorded is returned for a request the tape has no response for.
+var ErrNotRecorded = errors.New("no recorded response")
+
+func (p *Player) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	url := sanitizeURL(req.URL.String())
+	key := matchKey(req.Method, url)
+
+	p.mu.Lock()
+	queue := p.queues[key]
+	i := p.current[key]
+	if i < len(queue)-1 {
+		p.current[key]++
+	}
+	p.mu.Unlock()
+
+	if len(queue) == 0 {
+		return nil, fmt.Errorf("%w for %s %s", ErrNotRecorded, req.Method, url)
+	}
+	rec := queue[i]
+
+	header := make(http.Header, len(rec.Header))
+	for name, v := range rec.Header {
+		header.Set(name, v)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.Status, http.StatusText(rec.Status)),
+		StatusCode:    rec.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(rec.Body)),
+		ContentLength: int64(len(rec.Body)),
+		Request:       req,
+	}, nil
+}
+
+// matchKey identifies a request by method and its URL as the tape stores
+// it, sanitized.
+func matchKey(method, url string) string {
+	return strings.ToUpper(method) + " " + url
+}
@@ -0,0 +1,189 @@
+package tape
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecorderSanitizesExchanges(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Set-Cookie", "session=abc123")
+		w.Header().Set("anthropic-ratelimit-tokens-remaining", "9000")
+		io.WriteString(w, `{"access_token":"at-live","account":{"email":"jane.doe@corp.example"}}`)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	rec, err := NewRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.Name = func(*http.Request) string { return "Claude" }
+	client := &http.Client{Transport: rec.Transport(nil)}
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/v1/oauth/token", strings.NewReader("grant_type=refresh_token&refresh_token=rt-live"))
+	req.Header.Set("Authorization", "Bearer at-old")
+	req.Header.Set("x-api-key", "k-live")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "at-live") {
+		t.Fatalf("the caller must get the real response, got %s", body)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "001-claude-post-token.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leak := range []string{"at-live", "at-old", "rt-live", "k-live", "abc123", "jane.doe"} {
+		if strings.Contains(string(data), leak) {
+			t.Fatalf("fixture leaks %q:\n%s", leak, data)
+		}
+	}
+
+	var ex Exchange
+	if err := json.Unmarshal(data, &ex); err != nil {
+		t.Fatal(err)
+	}
+	if ex.Response.Header["Anthropic-Ratelimit-Tokens-Remaining"] != "9000" {
+		t.Fatalf("rate-limit header lost: %v", ex.Response.Header)
+	}
+	if !strings.Contains(ex.Response.Body, placeholderEmail) {
+		t.Fatalf("expected the placeholder email, got %s", ex.Response.Body)
+	}
+}
+
+func TestRecorderRedactsIdentityFields(t *testing.T) {
+	profile := `{"account":{"uuid":"7c0e3a52-1b9f-4c8e-9d2a-5f6b7c8d9e0f","full_name":"Jane Q. Doe","display_name":"Jane",` +
+		`"email":"jane.doe@corp.example","has_claude_max":true},` +
+		`"organization":{"uuid":"0a1b2c3d-4e5f-6789-abcd-ef0123456789","name":"Doe Consulting LLC","organization_type":"claude_max",` +
+		`"billing_type":"stripe_subscription","rate_limit_tier":"default_claude_max_20x"},` +
+		`"members":[{"login":"janedoe","account_id":"acct_98765"}]}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		io.WriteString(w, profile)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	rec, err := NewRecorder(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: rec.Transport(nil)}
+	resp, err := client.Get(srv.URL + "/api/oauth/profile?user=user_01ABC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, "001-get-profile.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leak := range []string{"7c0e3a52", "0a1b2c3d", "Jane", "Doe", "janedoe", "acct_98765", "user_01ABC"} {
+		if strings.Contains(string(data), leak) {
+			t.Fatalf("fixture leaks %q:\n%s", leak, data)
+		}
+	}
+
+	var ex Exchange
+	if err := json.Unmarshal(data, &ex); err != nil {
+		t.Fatal(err)
+	}
+	var body struct {
+		Account struct {
+			Email        string `json:"email"`
+			HasClaudeMax bool   `json:"has_claude_max"`
+		} `json:"account"`
+		Organization struct {
+			Name string `json:"name"`
+			Tier string `json:"rate_limit_tier"`
+		} `json:"organization"`
+	}
+	if err := json.Unmarshal([]byte(ex.Response.Body), &body); err != nil {
+		t.Fatalf("sanitized body is not JSON: %v", err)
+	}
+	if body.Account.Email != placeholderEmail || !body.Account.HasClaudeMax || body.Organization.Name != redacted || body.Organization.Tier != "default_claude_max_20x" {
+		t.Fatalf("unexpected sanitized profile: %s", ex.Response.Body)
+	}
+}
+
+func writeExchange(t *testing.T, dir, name string, ex Exchange) {
+	t.Helper()
+	data, err := json.Marshal(ex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPlayerServesResponsesInOrder(t *testing.T) {
+	dir := t.TempDir()
+	usage := Request{Method: http.MethodGet, URL: "https://api.example.test/usage"}
+	writeExchange(t, dir, "001.json", Exchange{Request: usage, Response: Response{Status: 401}})
+	writeExchange(t, dir, "002.json", Exchange{Request: usage, Response: Response{Status: 200, Header: map[string]string{"Content-Type": "application/json"}, Body: `{"ok":true}`}})
+
+	p, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: p}
+
+	var statuses []int
+	for range 3 {
+		resp, err := client.Get(usage.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		statuses = append(statuses, resp.StatusCode)
+	}
+	if statuses[0] != 401 || statuses[1] != 200 || statuses[2] != 200 {
+		t.Fatalf("unexpected statuses %v", statuses)
+	}
+
+	_, err = client.Get("https://api.example.test/profile")
+	if !errors.Is(err, ErrNotRecorded) {
+		t.Fatalf("expected ErrNotRecorded, got %v", err)
+	}
+}
+
+func TestPlayerListsProviders(t *testing.T) {
+	dir := t.TempDir()
+	req := Request{Method: http.MethodGet, URL: "https://api.example.test/usage"}
+	writeExchange(t, dir, "001.json", Exchange{Provider: "Codex", Request: req, Response: Response{Status: 200}})
+	writeExchange(t, dir, "002.json", Exchange{Provider: "Claude", Request: req, Response: Response{Status: 200}})
+	writeExchange(t, dir, "003.json", Exchange{Provider: "Codex", Request: req, Response: Response{Status: 200}})
+
+	p, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(p.Providers(), ","); got != "Codex,Claude" {
+		t.Fatalf("unexpected providers %q", got)
+	}
+}
+
+func TestLoadRejectsEmptyOrBrokenTapes(t *testing.T) {
+	if _, err := Load(t.TempDir()); err == nil {
+		t.Fatal("expected an error for an empty directory")
+	}
+
+	dir := t.TempDir()
+	writeExchange(t, dir, "001.json", Exchange{Request: Request{Method: http.MethodGet}})
+	if _, err := Load(dir); err == nil {
+		t.Fatal("expected an error for a fixture without a URL")
+	}
+}
@@ -0,0 +1,99 @@
+package transcripts
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type claudeLine struct {
+	Type      string `json:"type"`
+	Timestamp string `json:"timestamp"`
+	Cwd       string `json:"cwd"`
+	RequestID string `json:"requestId"`
+	Message   *struct {
+		ID    string `json:"id"`
+		Model string `json:"model"`
+		Usage *struct {
+			InputTokens              int64 `json:"input_tokens"`
+			OutputTokens             int64 `json:"output_tokens"`
+			CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+			CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+}
+
+// ClaudeDir returns where Claude Code keeps session transcripts.
+func ClaudeDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".claude", "projects"), nil
+}
+
+// Claude returns a scanner for Claude Code session transcripts, which live
+// in root (normally ~/.claude/projects) as one JSONL file per session.
+func Claude(root string) Scanner {
+	return Scanner{
+		StateName: "claude-transcripts.json",
+		Root:      root,
+		Match:     func(path string) bool { return strings.HasSuffix(path, ".jsonl") },
+		Parse:     parseClaudeLine,
+	}
+}
+
+// parseClaudeLine reads usage from assistant messages. A response split
+// over several content blocks is logged once per block with the same
+// usage, and a resumed session copies earlier messages into its new
+// transcript, so events carry the message and request ids for Scan to
+// count each response once.
+func parseClaudeLine(line []byte, f *File) (Event, bool) {
+	// Cheap pre-filter: most lines are user messages and tool output.
+	if !strings.Contains(string(line), `"usage"`) {
+		return Event{}, false
+	}
+
+	var l claudeLine
+	if err := json.Unmarshal(line, &l); err != nil {
+		return Event{}, false
+	}
+	if l.Type != "assistant" || l.Message == nil || l.Message.Usage == nil {
+		return Event{}, false
+	}
+	if l.Message.Model == "" || l.Message.Model == "<synthetic>" {
+		return Event{}, false
+	}
+
+	t, err := time.Parse(time.RFC3339, l.Timestamp)
+	if err != nil {
+		return Event{}, false
+	}
+
+	project := l.Cwd
+	if project == "" {
+		project = f.Project
+	}
+	f.Project = project
+
+	var id string
+	if l.Message.ID != "" {
+		id = l.Message.ID + ":" + l.RequestID
+	}
+
+	u := l.Message.Usage
+	return Event{
+		Time:    t,
+		Model:   l.Message.Model,
+		Project: project,
+		ID:      id,
+		Tokens: Tokens{
+			Input:      u.InputTokens,
+			Output:     u.OutputTokens,
+			CacheWrite: u.CacheCreationInputTokens,
+			CacheRead:  u.CacheReadInputTokens,
+		},
+	}, true
+}
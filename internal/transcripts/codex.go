@@ -0,0 +1,260 @@
+package transcripts
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type codexLine struct {
+	Timestamp string          `json:"timestamp"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+type codexContext struct {
+	Cwd   string `json:"cwd"`
+	Model string `json:"model"`
+}
+
+type codexEvent struct {
+	Type string `json:"type"`
+	Info *struct {
+		TotalTokenUsage codexTokenUsage `json:"total_token_usage"`
+		LastTokenUsage  codexTokenUsage `json:"last_token_usage"`
+	} `json:"info"`
+}
+
+type codexTokenUsage struct {
+	InputTokens       int64 `json:"input_tokens"`
+	CachedInputTokens int64 `json:"cached_input_tokens"`
+	OutputTokens      int64 `json:"output_tokens"`
+	TotalTokens       int64 `json:"total_tokens"`
+}
+
+// codexUnknownModel labels usage logged before any turn context named a
+// model.
+const codexUnknownModel = "unknown"
+
+// CodexDir returns where the Codex CLI keeps session logs.
+func CodexDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".codex", "sessions"), nil
+}
+
+// Codex returns a scanner for Codex CLI rollout logs, which live in root
+// (normally ~/.codex/sessions) as YYYY/MM/DD/rollout-*.jsonl.
+func Codex(root string) Scanner {
+	return Scanner{
+		StateName: "codex-sessions.json",
+		Root:      root,
+		Match: func(path string) bool {
+			name := filepath.Base(path)
+			return strings.HasPrefix(name, "rollout-") && strings.HasSuffix(name, ".jsonl")
+		},
+		Parse: parseCodexLine,
+	}
+}
+
+// parseCodexLine tracks the session's working directory and model from
+// session_meta and turn_context lines, and reads per-turn usage from
+// token_count events. The CLI may repeat a token_count without new usage,
+// so events whose running total hasn't changed are ignored.
+func parseCodexLine(line []byte, f *File) (Event, bool) {
+	var l codexLine
+	if err := json.Unmarshal(line, &l); err != nil {
+		return Event{}, false
+	}
+
+	switch l.Type {
+	case "session_meta", "turn_context":
+		var c codexContext
+		if json.Unmarshal(l.Payload, &c) == nil {
+			if c.Cwd != "" {
+				f.Project = c.Cwd
+			}
+			if c.Model != "" {
+				f.Model = c.Model
+			}
+		}
+		return Event{}, false
+	case "event_msg":
+	default:
+		return Event{}, false
+	}
+
+	var ev codexEvent
+	if err := json.Unmarshal(l.Payload, &ev); err != nil || ev.Type != "token_count" || ev.Info == nil {
+		return Event{}, false
+	}
+
+	total := strconv.FormatInt(ev.Info.TotalTokenUsage.TotalTokens, 10)
+	if total == f.LastID {
+		return Event{}, false
+	}
+	f.LastID = total
+
+	t, err := time.Parse(time.RFC3339, l.Timestamp)
+	if err != nil {
+		return Event{}, false
+	}
+
+	model := f.Model
+	if model == "" {
+		model = codexUnknownModel
+	}
+
+	// input_tokens includes the cached portion; split it out so cache reads
+	// are counted once.
+	u := ev.Info.LastTokenUsage
+	return Event{
+		Time:    t,
+		Model:   model,
+		Project: f.Project,
+		Tokens: Tokens{
+			Input:     u.InputTokens - u.CachedInputTokens,
+			Output:    u.OutputTokens,
+			CacheRead: u.CachedInputTokens,
+		},
+	}, true
+}
+
+// RateLimits is a rate-limit snapshot the Codex CLI logged after a turn.
+type RateLimits struct {
+	Time      time.Time
+	Primary   *RateLimitWindow
+	Secondary *RateLimitWindow
+}
+
+type RateLimitWindow struct {
+	UsedPercent   float64
+	WindowMinutes int
+	ResetsAt      time.Time // zero when the log didn't say
+}
+
+type codexRateLimitEvent struct {
+	Type       string `json:"type"`
+	RateLimits *struct {
+		Primary   *codexRateLimitWindow `json:"primary"`
+		Secondary *codexRateLimitWindow `json:"secondary"`
+	} `json:"rate_limits"`
+}
+
+type codexRateLimitWindow struct {
+	UsedPercent     float64 `json:"used_percent"`
+	WindowMinutes   int     `json:"window_minutes"`
+	ResetsAt        int64   `json:"resets_at"`
+	ResetsInSeconds int64   `json:"resets_in_seconds"`
+}
+
+const (
+	// codexSnapshotFiles bounds how many recent logs are searched.
+	codexSnapshotFiles = 5
+	// codexSnapshotTail is how much of the end of each log is read.
+	codexSnapshotTail = 512 << 10
+)
+
+// LatestCodexRateLimits returns the freshest rate-limit snapshot from the
+// most recently written rollout logs under root.
+func LatestCodexRateLimits(root string) (RateLimits, bool) {
+	type logFile struct {
+		path string
+		mod  time.Time
+	}
+	var files []logFile
+	match := Codex(root).Match
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !match(path) {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			files = append(files, logFile{path: path, mod: info.ModTime()})
+		}
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool { return files[i].mod.After(files[j].mod) })
+
+	var best RateLimits
+	found := false
+	for i, f := range files {
+		if i == codexSnapshotFiles {
+			break
+		}
+		if rl, ok := lastCodexRateLimits(f.path); ok && (!found || rl.Time.After(best.Time)) {
+			best, found = rl, true
+		}
+	}
+	return best, found
+}
+
+func lastCodexRateLimits(path string) (RateLimits, bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return RateLimits{}, false
+	}
+	defer file.Close()
+
+	if info, err := file.Stat(); err == nil && info.Size() > codexSnapshotTail {
+		if _, err := file.Seek(info.Size()-codexSnapshotTail, io.SeekStart); err != nil {
+			return RateLimits{}, false
+		}
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return RateLimits{}, false
+	}
+
+	var latest RateLimits
+	found := false
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if !bytes.Contains(line, []byte(`"rate_limits"`)) {
+			continue
+		}
+		var l codexLine
+		if json.Unmarshal(line, &l) != nil || l.Type != "event_msg" {
+			continue // includes a partial first line from the tail seek
+		}
+		var ev codexRateLimitEvent
+		if json.Unmarshal(l.Payload, &ev) != nil || ev.Type != "token_count" || ev.RateLimits == nil {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, l.Timestamp)
+		if err != nil {
+			continue
+		}
+
+		rl := RateLimits{
+			Time:      t,
+			Primary:   codexWindow(ev.RateLimits.Primary, t),
+			Secondary: codexWindow(ev.RateLimits.Secondary, t),
+		}
+		if rl.Primary != nil || rl.Secondary != nil {
+			latest, found = rl, true
+		}
+	}
+	return latest, found
+}
+
+func codexWindow(w *codexRateLimitWindow, at time.Time) *RateLimitWindow {
+	if w == nil {
+		return nil
+	}
+	out := &RateLimitWindow{UsedPercent: w.UsedPercent, WindowMinutes: w.WindowMinutes}
+	switch {
+	case w.ResetsAt > 0:
+		out.ResetsAt = time.Unix(w.ResetsAt, 0)
+	case w.ResetsInSeconds > 0:
+		out.ResetsAt = at.Add(time.Duration(w.ResetsInSeconds) * time.Second)
+	}
+	return out
+}
@@ -0,0 +1,98 @@
+package transcripts
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCodexScanTracksContextAndSkipsRepeats(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	root := t.TempDir()
+	dir := filepath.Join(root, "2026", "03", "04")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	log := `{"timestamp":"2026-03-04T09:00:00Z","type":"session_meta","payload":{"id":"s1","cwd":"/home/dev/api"}}
+{"timestamp":"2026-03-04T09:00:01Z","type":"turn_context","payload":{"cwd":"/home/dev/api","model":"gpt-5-codex"}}
+{"timestamp":"2026-03-04T09:00:02Z","type":"event_msg","payload":{"type":"token_count","info":null}}
+{"timestamp":"2026-03-04T09:00:05Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"total_tokens":1150},"last_token_usage":{"input_tokens":1000,"cached_input_tokens":800,"output_tokens":150,"total_tokens":1150}}}}
+{"timestamp":"2026-03-04T09:00:06Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"total_tokens":1150},"last_token_usage":{"input_tokens":1000,"cached_input_tokens":800,"output_tokens":150,"total_tokens":1150}}}}
+{"timestamp":"2026-03-04T09:05:00Z","type":"turn_context","payload":{"cwd":"/home/dev/web","model":"gpt-5"}}
+{"timestamp":"2026-03-04T09:05:09Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"total_tokens":1260},"last_token_usage":{"input_tokens":100,"cached_input_tokens":0,"output_tokens":10,"total_tokens":110}}}}
+`
+	if err := os.WriteFile(filepath.Join(dir, "rollout-2026-03-04T09-00-00-s1.jsonl"), []byte(log), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.jsonl"), []byte(log), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	buckets, err := Codex(root).Scan(context.Background(), time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if len(buckets) != 2 {
+		t.Fatalf("expected two buckets, got %+v", buckets)
+	}
+
+	first, second := buckets[0], buckets[1]
+	if first.Model != "gpt-5-codex" || first.Project != "/home/dev/api" {
+		t.Fatalf("unexpected first bucket context: %+v", first)
+	}
+	if first.Tokens != (Tokens{Input: 200, Output: 150, CacheRead: 800}) {
+		t.Fatalf("expected repeated token_count to be skipped and cache split out, got %+v", first.Tokens)
+	}
+	if second.Model != "gpt-5" || second.Project != "/home/dev/web" || second.Input != 100 {
+		t.Fatalf("unexpected second bucket: %+v", second)
+	}
+}
+
+func TestLatestCodexRateLimits(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "2026", "03", "04")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	older := `{"timestamp":"2026-03-04T08:00:00Z","type":"event_msg","payload":{"type":"token_count","info":null,"rate_limits":{"primary":{"used_percent":90,"window_minutes":300,"resets_at":1772625600}}}}
+`
+	newer := `{"timestamp":"2026-03-04T10:00:00Z","type":"event_msg","payload":{"type":"token_count","info":null,"rate_limits":{"primary":{"used_percent":40,"window_minutes":300,"resets_in_seconds":3600},"secondary":{"used_percent":12,"window_minutes":10080,"resets_at":1773014400}}}}
+{"timestamp":"2026-03-04T10:00:01Z","type":"event_msg","payload":{"type":"token_count","info":null,"rate_limits":{}}}
+{"timestamp":"2026-03-04T10:00:02Z","type":"response_item","payload":{"type":"message"}}
+`
+	olderPath := filepath.Join(dir, "rollout-a.jsonl")
+	newerPath := filepath.Join(dir, "rollout-b.jsonl")
+	if err := os.WriteFile(olderPath, []byte(older), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(newerPath, []byte(newer), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(olderPath, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	rl, ok := LatestCodexRateLimits(root)
+	if !ok {
+		t.Fatal("expected a rate-limit snapshot")
+	}
+	at := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	if !rl.Time.Equal(at) {
+		t.Fatalf("expected the newest snapshot, got %v", rl.Time)
+	}
+	if rl.Primary == nil || rl.Primary.UsedPercent != 40 || !rl.Primary.ResetsAt.Equal(at.Add(time.Hour)) {
+		t.Fatalf("unexpected primary window: %+v", rl.Primary)
+	}
+	if rl.Secondary == nil || rl.Secondary.WindowMinutes != 10080 || !rl.Secondary.ResetsAt.Equal(time.Unix(1773014400, 0)) {
+		t.Fatalf("unexpected secondary window: %+v", rl.Secondary)
+	}
+
+	if _, ok := LatestCodexRateLimits(filepath.Join(root, "missing")); ok {
+		t.Fatal("expected no snapshot for a missing directory")
+	}
+}
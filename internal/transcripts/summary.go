@@ -0,0 +1,77 @@
+package transcripts
+
+import (
+	"sort"
+	"time"
+)
+
+// Period is a labelled time range starting at Since and running to now.
+type Period struct {
+	Label string
+	Since time.Time
+}
+
+// Row is a token total for one model or project.
+type Row struct {
+	Label string
+	Tokens
+}
+
+// Summary is the token total for a period, split by model and by project,
+// each sorted by total descending.
+type Summary struct {
+	Label string
+	Tokens
+	Models   []Row
+	Projects []Row
+}
+
+// Summarize totals buckets for each period.
+func Summarize(buckets []Bucket, periods []Period) []Summary {
+	out := make([]Summary, 0, len(periods))
+	for _, p := range periods {
+		since := p.Since.Unix()
+		s := Summary{Label: p.Label}
+		models := map[string]*Tokens{}
+		projects := map[string]*Tokens{}
+
+		for _, b := range buckets {
+			if b.Minute < since {
+				continue
+			}
+			s.Add(b.Tokens)
+			addRow(models, b.Model, b.Tokens)
+			if b.Project != "" {
+				addRow(projects, b.Project, b.Tokens)
+			}
+		}
+
+		s.Models = sortedRows(models)
+		s.Projects = sortedRows(projects)
+		out = append(out, s)
+	}
+	return out
+}
+
+func addRow(rows map[string]*Tokens, label string, t Tokens) {
+	r := rows[label]
+	if r == nil {
+		r = &Tokens{}
+		rows[label] = r
+	}
+	r.Add(t)
+}
+
+func sortedRows(rows map[string]*Tokens) []Row {
+	out := make([]Row, 0, len(rows))
+	for label, t := range rows {
+		out = append(out, Row{Label: label, Tokens: *t})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Total() != out[j].Total() {
+			return out[i].Total() > out[j].Total()
+		}
+		return out[i].Label < out[j].Label
+	})
+	return out
+}
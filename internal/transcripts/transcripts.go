@@ -0,0 +1,377 @@
+// Package transcripts incrementally scans local JSONL session logs written
+// by coding CLIs and keeps per-minute token totals, so usage can be shown
+// without any network access.
+package transcripts
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// DefaultRetention is how long minute buckets are kept; it covers a month
+// of history plus slack for periods that straddle a month boundary.
+const DefaultRetention = 35 * 24 * time.Hour
+
+// Tokens counts tokens by kind.
+type Tokens struct {
+	Input      int64 `json:"input,omitempty"`
+	Output     int64 `json:"output,omitempty"`
+	CacheWrite int64 `json:"cache_write,omitempty"`
+	CacheRead  int64 `json:"cache_read,omitempty"`
+}
+
+func (t *Tokens) Add(o Tokens) {
+	t.Input += o.Input
+	t.Output += o.Output
+	t.CacheWrite += o.CacheWrite
+	t.CacheRead += o.CacheRead
+}
+
+func (t Tokens) Total() int64 {
+	return t.Input + t.Output + t.CacheWrite + t.CacheRead
+}
+
+// Bucket holds the tokens used in one minute for one model and project.
+type Bucket struct {
+	Minute  int64  `json:"minute"` // unix seconds, truncated to the minute
+	Model   string `json:"model"`
+	Project string `json:"project,omitempty"`
+	Tokens
+}
+
+// File records how far a log has been read, plus context that a log format
+// carries from earlier lines to later ones (e.g. the active model). It also
+// keeps what the log has contributed so far, so a rewritten log can be
+// recounted from scratch.
+type File struct {
+	Offset  int64  `json:"offset"`
+	Model   string `json:"model,omitempty"`
+	Project string `json:"project,omitempty"`
+	LastID  string `json:"last_id,omitempty"`
+
+	Buckets []Bucket         `json:"buckets,omitempty"`
+	IDs     map[string]int64 `json:"ids,omitempty"` // event ID -> minute
+}
+
+// Event is one usage record parsed from a log line.
+type Event struct {
+	Time    time.Time
+	Model   string
+	Project string
+	// ID identifies the request the event reports, if the format has one.
+	// An ID already counted from any log is skipped, since some CLIs copy
+	// earlier messages into a new log when a session is resumed.
+	ID string
+	Tokens
+}
+
+// Scanner reads new lines from every log under Root accepted by Match and
+// turns them into buckets with Parse. Offsets and buckets are persisted in
+// the cache directory under StateName, so each run only reads what was
+// appended since the last one.
+type Scanner struct {
+	StateName string
+	Root      string
+	Match     func(path string) bool
+	// Parse returns the usage event on line, if any. It may read and update
+	// the file's carried context.
+	Parse     func(line []byte, f *File) (Event, bool)
+	Retention time.Duration
+}
+
+// state is what Scan persists. Buckets and IDs hold what logs that have
+// since been removed contributed; live logs keep theirs in their File.
+type state struct {
+	Files   map[string]*File `json:"files"`
+	Buckets []Bucket         `json:"buckets"`
+	IDs     map[string]int64 `json:"ids,omitempty"`
+}
+
+type bucketKey struct {
+	minute         int64
+	model, project string
+}
+
+// Scan reads any new log lines, saves the updated state and returns all
+// retained buckets. A missing Root yields no buckets and no error. If ctx
+// ends mid-scan, the progress made so far is still saved and returned,
+// along with ctx's error, so the next scan picks up where this one
+// stopped.
+func (s Scanner) Scan(ctx context.Context, now time.Time) ([]Bucket, error) {
+	if _, err := os.Stat(s.Root); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	retention := s.Retention
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	cutoff := now.Add(-retention)
+
+	st := s.load()
+	counted := map[string]bool{}
+	for id := range st.IDs {
+		counted[id] = true
+	}
+	for _, f := range st.Files {
+		for id := range f.IDs {
+			counted[id] = true
+		}
+	}
+
+	seen := map[string]*File{}
+	err := filepath.WalkDir(s.Root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // unreadable entries are skipped, not fatal
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if d.IsDir() || !s.Match(path) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		f := st.Files[path]
+		if f != nil && info.Size() < f.Offset {
+			// Rewritten: forget what it contributed and count it afresh.
+			for id := range f.IDs {
+				delete(counted, id)
+			}
+			f = nil
+		}
+		if f == nil {
+			f = &File{}
+		}
+		seen[path] = f
+
+		if info.Size() == f.Offset {
+			return nil
+		}
+		if f.Offset == 0 && info.ModTime().Before(cutoff) {
+			f.Offset = info.Size() // nothing in it is recent enough to keep
+			return nil
+		}
+
+		return s.readFile(ctx, path, f, cutoff, counted)
+	})
+	if err != nil && ctx.Err() == nil {
+		return nil, err
+	}
+	for path, f := range st.Files {
+		if _, ok := seen[path]; ok {
+			continue
+		}
+		if err != nil {
+			// Files the walk didn't reach keep their offsets; only a
+			// complete walk can tell that a file was removed.
+			seen[path] = f
+			continue
+		}
+		st.Buckets = append(st.Buckets, f.Buckets...)
+		for id, minute := range f.IDs {
+			if st.IDs == nil {
+				st.IDs = map[string]int64{}
+			}
+			st.IDs[id] = minute
+		}
+	}
+	st.Files = seen
+
+	st.Buckets, st.IDs = prune(st.Buckets, st.IDs, cutoff)
+	all := st.Buckets
+	for _, f := range st.Files {
+		f.Buckets, f.IDs = prune(f.Buckets, f.IDs, cutoff)
+		all = append(all, f.Buckets...)
+	}
+
+	if saveErr := s.save(st); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	return merge(all), err
+}
+
+// prune drops buckets and IDs older than cutoff, merging buckets that share
+// a key.
+func prune(buckets []Bucket, ids map[string]int64, cutoff time.Time) ([]Bucket, map[string]int64) {
+	var kept []Bucket
+	for _, b := range merge(buckets) {
+		if b.Minute >= cutoff.Unix() {
+			kept = append(kept, b)
+		}
+	}
+	for id, minute := range ids {
+		if minute < cutoff.Unix() {
+			delete(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		ids = nil
+	}
+	return kept, ids
+}
+
+// merge sums buckets that share a minute, model and project, and sorts the
+// result.
+func merge(buckets []Bucket) []Bucket {
+	index := map[bucketKey]int{}
+	var out []Bucket
+	for _, b := range buckets {
+		key := bucketKey{b.Minute, b.Model, b.Project}
+		if i, ok := index[key]; ok {
+			out[i].Add(b.Tokens)
+			continue
+		}
+		index[key] = len(out)
+		out = append(out, b)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Minute != b.Minute {
+			return a.Minute < b.Minute
+		}
+		if a.Model != b.Model {
+			return a.Model < b.Model
+		}
+		return a.Project < b.Project
+	})
+	return out
+}
+
+// readFile consumes complete lines after f.Offset into f's buckets; a
+// trailing partial line is left for the next scan. Events whose ID is in
+// counted are skipped, and new IDs are added to it. It stops early, with
+// ctx's error, if ctx ends; f.Offset then points just past the last line
+// counted.
+func (s Scanner) readFile(ctx context.Context, path string, f *File, cutoff time.Time, counted map[string]bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(f.Offset, io.SeekStart); err != nil {
+		return nil
+	}
+
+	index := map[bucketKey]int{}
+	for i, b := range f.Buckets {
+		index[bucketKey{b.Minute, b.Model, b.Project}] = i
+	}
+
+	r := bufio.NewReaderSize(file, 64<<10)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			return nil // EOF or a partial line: stop before it
+		}
+		f.Offset += int64(len(line))
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		ev, ok := s.Parse(line, f)
+		if !ok || ev.Time.Before(cutoff) {
+			continue
+		}
+
+		minute := ev.Time.Truncate(time.Minute).Unix()
+		if ev.ID != "" {
+			if counted[ev.ID] {
+				continue
+			}
+			counted[ev.ID] = true
+			if f.IDs == nil {
+				f.IDs = map[string]int64{}
+			}
+			f.IDs[ev.ID] = minute
+		}
+
+		key := bucketKey{minute, ev.Model, ev.Project}
+		i, ok := index[key]
+		if !ok {
+			i = len(f.Buckets)
+			index[key] = i
+			f.Buckets = append(f.Buckets, Bucket{Minute: minute, Model: ev.Model, Project: ev.Project})
+		}
+		f.Buckets[i].Add(ev.Tokens)
+	}
+}
+
+func (s Scanner) statePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ai-usage-bar", s.StateName), nil
+}
+
+// load returns the saved state, or an empty one if it is missing or
+// corrupt; a rescan rebuilds it.
+func (s Scanner) load() state {
+	st := state{Files: map[string]*File{}}
+
+	path, err := s.statePath()
+	if err != nil {
+		return st
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return st
+	}
+	if err := json.Unmarshal(data, &st); err != nil || st.Files == nil {
+		return state{Files: map[string]*File{}}
+	}
+	return st
+}
+
+// save writes the state atomically so concurrent runs never see a torn file.
+func (s Scanner) save(st state) error {
+	path, err := s.statePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), s.StateName+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
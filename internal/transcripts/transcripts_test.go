@@ -0,0 +1,264 @@
+package transcripts
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func claudeAssistantLine(id, ts, model, cwd string, in, out int) string {
+	return `{"type":"assistant","timestamp":"` + ts + `","cwd":"` + cwd + `","requestId":"req_` + id + `",` +
+		`"message":{"id":"msg_` + id + `","model":"` + model + `","usage":{"input_tokens":` + strconv.Itoa(in) +
+		`,"output_tokens":` + strconv.Itoa(out) + `,"cache_creation_input_tokens":100,"cache_read_input_tokens":1000}}}` + "\n"
+}
+
+func appendFile(t *testing.T, path, data string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func totalTokens(buckets []Bucket) Tokens {
+	var t Tokens
+	for _, b := range buckets {
+		t.Add(b.Tokens)
+	}
+	return t
+}
+
+func TestClaudeScanIsIncremental(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	root := t.TempDir()
+	dir := filepath.Join(root, "-home-dev-repo")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "session.jsonl")
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	line := claudeAssistantLine("1", "2026-03-04T10:00:05Z", "claude-sonnet", "/home/dev/repo", 10, 20)
+	appendFile(t, path, `{"type":"user","message":{"content":"hi"}}`+"\n")
+	appendFile(t, path, line)
+	appendFile(t, path, line) // same message logged again for a second content block
+
+	s := Claude(root)
+	buckets, err := s.Scan(context.Background(), now)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if got := totalTokens(buckets); got != (Tokens{Input: 10, Output: 20, CacheWrite: 100, CacheRead: 1000}) {
+		t.Fatalf("unexpected totals after first scan: %+v", got)
+	}
+	if buckets[0].Project != "/home/dev/repo" || buckets[0].Minute != time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC).Unix() {
+		t.Fatalf("unexpected bucket: %+v", buckets[0])
+	}
+
+	// A complete line plus a partial one still being written.
+	second := claudeAssistantLine("2", "2026-03-04T11:30:00Z", "claude-opus", "/home/dev/repo", 1, 2)
+	appendFile(t, path, second)
+	partial := claudeAssistantLine("3", "2026-03-04T11:31:00Z", "claude-opus", "/home/dev/repo", 5, 5)
+	appendFile(t, path, partial[:40])
+
+	buckets, err = s.Scan(context.Background(), now)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if got := totalTokens(buckets); got.Input != 11 || got.Output != 22 {
+		t.Fatalf("expected only the new complete line to be added, got %+v", got)
+	}
+
+	appendFile(t, path, partial[40:])
+	buckets, err = s.Scan(context.Background(), now)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if got := totalTokens(buckets); got.Input != 16 || got.Output != 27 {
+		t.Fatalf("expected the finished line to be added once, got %+v", got)
+	}
+}
+
+func TestScanDropsOldBucketsAndRemovedFiles(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	root := t.TempDir()
+	path := filepath.Join(root, "session.jsonl")
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	appendFile(t, path, claudeAssistantLine("old", "2026-01-01T00:00:00Z", "claude-sonnet", "", 99, 99))
+	appendFile(t, path, claudeAssistantLine("new", "2026-03-04T09:00:00Z", "claude-sonnet", "", 1, 1))
+
+	s := Claude(root)
+	buckets, err := s.Scan(context.Background(), now)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if len(buckets) != 1 || buckets[0].Input != 1 {
+		t.Fatalf("expected only the in-retention bucket, got %+v", buckets)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Scan(context.Background(), now); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if st := s.load(); len(st.Files) != 0 || len(st.Buckets) != 1 {
+		t.Fatalf("expected removed file to be forgotten but buckets kept, got %d files %d buckets", len(st.Files), len(st.Buckets))
+	}
+
+	// Buckets age out as time moves on.
+	buckets, err = s.Scan(context.Background(), now.Add(DefaultRetention))
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if len(buckets) != 0 {
+		t.Fatalf("expected buckets past retention to be dropped, got %+v", buckets)
+	}
+}
+
+func TestScanRereadsTruncatedFile(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	root := t.TempDir()
+	path := filepath.Join(root, "session.jsonl")
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	appendFile(t, path, claudeAssistantLine("1", "2026-03-04T09:00:00Z", "claude-sonnet", "", 1, 1))
+	appendFile(t, path, claudeAssistantLine("2", "2026-03-04T09:01:00Z", "claude-sonnet", "", 1, 1))
+
+	s := Claude(root)
+	if _, err := s.Scan(context.Background(), now); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(claudeAssistantLine("3", "2026-03-04T10:00:00Z", "claude-sonnet", "", 5, 5)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	buckets, err := s.Scan(context.Background(), now)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if got := totalTokens(buckets); got.Input != 5 {
+		t.Fatalf("expected rewritten file to replace what it counted before, got %+v", got)
+	}
+}
+
+func TestClaudeResumedSessionIsCountedOnce(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	root := t.TempDir()
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	first := claudeAssistantLine("1", "2026-03-04T09:00:00Z", "claude-sonnet", "", 1, 1)
+	appendFile(t, filepath.Join(root, "a.jsonl"), first)
+
+	s := Claude(root)
+	if _, err := s.Scan(context.Background(), now); err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+
+	// Resuming copies the earlier exchange into the new session's log.
+	resumed := filepath.Join(root, "b.jsonl")
+	appendFile(t, resumed, first)
+	appendFile(t, resumed, claudeAssistantLine("2", "2026-03-04T10:00:00Z", "claude-sonnet", "", 2, 2))
+
+	buckets, err := s.Scan(context.Background(), now)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if got := totalTokens(buckets); got.Input != 3 {
+		t.Fatalf("expected the copied message to be counted once, got %+v", got)
+	}
+
+	// The count survives the original log being removed.
+	if err := os.Remove(filepath.Join(root, "a.jsonl")); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, filepath.Join(root, "c.jsonl"), first)
+	buckets, err = s.Scan(context.Background(), now)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if got := totalTokens(buckets); got.Input != 3 {
+		t.Fatalf("expected removed logs' messages to stay counted once, got %+v", got)
+	}
+}
+
+func TestScanKeepsProgressWhenCancelled(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	root := t.TempDir()
+	path := filepath.Join(root, "session.jsonl")
+	now := time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	for i := range 3 {
+		appendFile(t, path, claudeAssistantLine(strconv.Itoa(i), "2026-03-04T09:00:00Z", "claude-sonnet", "", 1, 1))
+	}
+
+	// Cancel as soon as the first event is parsed.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := Claude(root)
+	parse := s.Parse
+	s.Parse = func(line []byte, f *File) (Event, bool) {
+		ev, ok := parse(line, f)
+		if ok {
+			cancel()
+		}
+		return ev, ok
+	}
+
+	buckets, err := s.Scan(ctx, now)
+	if err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if got := totalTokens(buckets); got.Input != 1 {
+		t.Fatalf("expected the line read before cancelling, got %+v", got)
+	}
+
+	buckets, err = Claude(root).Scan(context.Background(), now)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if got := totalTokens(buckets); got.Input != 3 {
+		t.Fatalf("expected the next scan to resume, got %+v", got)
+	}
+}
+
+func TestScanMissingRoot(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	buckets, err := Claude(filepath.Join(t.TempDir(), "missing")).Scan(context.Background(), time.Now())
+	if err != nil || buckets != nil {
+		t.Fatalf("expected no buckets and no error, got %v %v", buckets, err)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	base := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+	buckets := []Bucket{
+		{Minute: base.Add(-2 * time.Hour).Unix(), Model: "opus", Project: "/a", Tokens: Tokens{Output: 100}},
+		{Minute: base.Add(1 * time.Hour).Unix(), Model: "sonnet", Project: "/a", Tokens: Tokens{Input: 5, Output: 5}},
+		{Minute: base.Add(2 * time.Hour).Unix(), Model: "opus", Project: "/b", Tokens: Tokens{Output: 20}},
+	}
+
+	got := Summarize(buckets, []Period{
+		{Label: "Today", Since: base},
+		{Label: "Week", Since: base.Add(-24 * time.Hour)},
+	})
+
+	if got[0].Total() != 30 || got[1].Total() != 130 {
+		t.Fatalf("unexpected totals: %d %d", got[0].Total(), got[1].Total())
+	}
+	if got[0].Models[0].Label != "opus" || got[0].Models[0].Output != 20 {
+		t.Fatalf("unexpected model ordering: %+v", got[0].Models)
+	}
+	if len(got[1].Projects) != 2 || got[1].Projects[0].Label != "/a" || got[1].Projects[0].Total() != 110 {
+		t.Fatalf("unexpected projects: %+v", got[1].Projects)
+	}
+}